@@ -1,40 +1,228 @@
 package main
 
 import (
-	"net/http"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
+	pb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
 )
 
+const usage = `Usage: client [flags] <command> [command flags]
+
+Commands:
+  register        register a new user
+  login           log in with email and password
+  get-user        get a user by id
+  get-profile     get a user profile by id
+  update-profile  update the profile of the authenticated user
+  delete-profile  delete a user profile by id
+
+Flags:
+`
+
+type options struct {
+	addr       string
+	useTLS     bool
+	caFile     string
+	serverName string
+	skipVerify bool
+	token      string
+	output     string
+	timeout    time.Duration
+}
+
+// command описывает подкоманду: разбирает свои флаги и вызывает RPC.
+type command struct {
+	auth bool
+	run  func(ctx context.Context, client pb.AccountsServiceClient, args []string) (proto.Message, error)
+}
+
+var commands = map[string]command{
+	"register":       {run: register},
+	"login":          {run: login},
+	"get-user":       {run: getUser},
+	"get-profile":    {run: getProfile},
+	"update-profile": {auth: true, run: updateProfile},
+	"delete-profile": {auth: true, run: deleteProfile},
+}
+
 func main() {
-	e := echo.New()
+	opts := options{}
 
-	e.Use(middleware.Logger())
-	e.Use(middleware.Recover())
+	flag.StringVar(&opts.addr, "addr", envOr("ACCOUNTS_ADDR", "localhost:8081"), "accounts server address (env ACCOUNTS_ADDR)")
+	flag.BoolVar(&opts.useTLS, "tls", false, "connect using TLS")
+	flag.StringVar(&opts.caFile, "ca", "", "CA certificate file to verify the server (implies -tls)")
+	flag.StringVar(&opts.serverName, "server-name", "", "server name to verify the certificate against")
+	flag.BoolVar(&opts.skipVerify, "insecure-skip-verify", false, "skip server certificate verification")
+	flag.StringVar(&opts.token, "token", os.Getenv("ACCOUNTS_TOKEN"), "access token (env ACCOUNTS_TOKEN)")
+	flag.StringVar(&opts.output, "o", "table", "output format: json or table")
+	flag.DurationVar(&opts.timeout, "timeout", 10*time.Second, "request timeout")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 
-	// for startup probe
-	e.GET("/health", func(c echo.Context) error {
-		return c.JSON(http.StatusOK, struct{ Status string }{Status: "OK"})
-	})
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
 
-	// for readiness probe
-	e.GET("/ready", func(c echo.Context) error {
-		return c.JSON(http.StatusOK, struct{ Status string }{Status: "OK"})
-	})
+	if err := run(opts, flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
 
-	e.GET("/hello", func(c echo.Context) error {
-		return c.HTML(http.StatusOK, "Hello, Docker!")
-	})
+func run(opts options, name string, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q", name)
+	}
+	if opts.output != "json" && opts.output != "table" {
+		return fmt.Errorf("unknown output format %q", opts.output)
+	}
+
+	creds, err := transportCredentials(opts)
+	if err != nil {
+		return err
+	}
 
-	httpPort := os.Getenv("PORT")
-	if httpPort == "" {
-		httpPort = "8081"
+	conn, err := grpc.NewClient(opts.addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
 	}
+	defer conn.Close()
 
-	time.Sleep(5 * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
 
-	e.Logger.Fatal(e.Start(":" + httpPort))
+	if opts.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+opts.token)
+	} else if cmd.auth {
+		return errors.New("this command requires -token or ACCOUNTS_TOKEN")
+	}
+
+	resp, err := cmd.run(ctx, pb.NewAccountsServiceClient(conn), args)
+	if err != nil {
+		return err
+	}
+
+	return printMessage(os.Stdout, opts.output, resp)
+}
+
+func transportCredentials(opts options) (credentials.TransportCredentials, error) {
+	if !opts.useTLS && opts.caFile == "" {
+		return insecure.NewCredentials(), nil
+	}
+
+	cfg := &tls.Config{
+		ServerName:         opts.serverName,
+		InsecureSkipVerify: opts.skipVerify,
+	}
+	if opts.caFile != "" {
+		pem, err := os.ReadFile(opts.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.caFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	return credentials.NewTLS(cfg), nil
+}
+
+func register(ctx context.Context, client pb.AccountsServiceClient, args []string) (proto.Message, error) {
+	fs := flag.NewFlagSet("register", flag.ExitOnError)
+	req := &pb.RegisterRequest{}
+	fs.StringVar(&req.Email, "email", "", "email")
+	fs.StringVar(&req.Name, "name", "", "name")
+	fs.StringVar(&req.Username, "username", "", "username")
+	fs.StringVar(&req.Description, "description", "", "information about yourself")
+	fs.StringVar(&req.Password, "password", "", "password")
+	fs.StringVar(&req.DeviceName, "device", deviceName(), "device name for the session")
+	fs.Parse(args)
+
+	return client.Register(ctx, req)
+}
+
+func login(ctx context.Context, client pb.AccountsServiceClient, args []string) (proto.Message, error) {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	req := &pb.LoginRequest{}
+	fs.StringVar(&req.Email, "email", "", "email")
+	fs.StringVar(&req.Password, "password", "", "password")
+	fs.StringVar(&req.DeviceName, "device", deviceName(), "device name for the session")
+	fs.Parse(args)
+
+	return client.Login(ctx, req)
+}
+
+func getUser(ctx context.Context, client pb.AccountsServiceClient, args []string) (proto.Message, error) {
+	fs := flag.NewFlagSet("get-user", flag.ExitOnError)
+	req := &pb.GetUserRequest{}
+	fs.Uint64Var(&req.Id, "id", 0, "user id")
+	fs.Parse(args)
+
+	return client.GetUser(ctx, req)
+}
+
+func getProfile(ctx context.Context, client pb.AccountsServiceClient, args []string) (proto.Message, error) {
+	fs := flag.NewFlagSet("get-profile", flag.ExitOnError)
+	req := &pb.GetProfileRequest{}
+	fs.Uint64Var(&req.Id, "id", 0, "user id")
+	fs.Parse(args)
+
+	return client.GetProfile(ctx, req)
+}
+
+func updateProfile(ctx context.Context, client pb.AccountsServiceClient, args []string) (proto.Message, error) {
+	fs := flag.NewFlagSet("update-profile", flag.ExitOnError)
+	req := &pb.UpdateProfileRequest{}
+	fs.StringVar(&req.Email, "email", "", "new email")
+	fs.StringVar(&req.Name, "name", "", "new name")
+	fs.StringVar(&req.Username, "username", "", "new username")
+	fs.StringVar(&req.Description, "description", "", "new information about yourself")
+	fs.Parse(args)
+
+	return client.UpdateProfile(ctx, req)
+}
+
+func deleteProfile(ctx context.Context, client pb.AccountsServiceClient, args []string) (proto.Message, error) {
+	fs := flag.NewFlagSet("delete-profile", flag.ExitOnError)
+	req := &pb.DeleteProfileRequest{}
+	fs.Uint64Var(&req.Id, "id", 0, "user id")
+	fs.Parse(args)
+
+	return client.DeleteProfile(ctx, req)
+}
+
+func deviceName() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "accounts-cli"
+	}
+	return "accounts-cli@" + strings.ToLower(hostname)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
 }
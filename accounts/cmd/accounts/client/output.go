@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var jsonOptions = protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}
+
+func printMessage(w io.Writer, format string, msg proto.Message) error {
+	if format == "json" {
+		body, err := jsonOptions.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(body))
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fields := msg.ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		fmt.Fprintf(tw, "%s\t%s\n", fd.JSONName(), formatValue(fd, msg.ProtoReflect().Get(fd)))
+	}
+	return tw.Flush()
+}
+
+func formatValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	if fd.IsList() {
+		return fmt.Sprintf("%d items", v.List().Len())
+	}
+	if fd.Message() == nil {
+		return v.String()
+	}
+	if ts, ok := v.Message().Interface().(*timestamppb.Timestamp); ok {
+		if !v.Message().IsValid() {
+			return ""
+		}
+		return ts.AsTime().Format(time.RFC3339)
+	}
+	body, _ := protojson.Marshal(v.Message().Interface())
+	return string(body)
+}
@@ -2,32 +2,77 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"errors"
+	"hash/fnv"
 	"log"
+	"log/slog"
 	"net"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
+	"time"
 
+	"github.com/zura-t/go_messenger/accounts/internal/idgen"
+	"github.com/zura-t/go_messenger/accounts/internal/session"
 	pb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
-
+	"github.com/zura-t/go_messenger/accounts/pkg/token"
+	"github.com/zura-t/go_messenger/platform/config"
+	"github.com/zura-t/go_messenger/platform/grpcpool"
+	"github.com/zura-t/go_messenger/platform/lifecycle"
+	"github.com/zura-t/go_messenger/platform/resilience"
+	"github.com/zura-t/go_messenger/platform/servicetoken"
+	rpb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-var idSerial uint64
+type user struct {
+	id           uint64
+	email        string
+	name         string
+	username     string
+	description  string
+	passwordHash []byte
+	createdAt    time.Time
+	updatedAt    time.Time
+
+	notifications notificationSettings
+}
 
 type server struct {
 	pb.UnimplementedAccountsServiceServer
 
+	ids       idgen.Generator
+	publicIDs *idgen.Codec
+	tokens    *token.Maker
+	sessions  *session.Store
+	relations rpb.RelationsServiceClient
+
 	mx    sync.RWMutex
-	users map[uint64]string
+	users map[uint64]*user
 }
 
-func NewServer() *server {
-	return &server{users: make(map[uint64]string)}
+func NewServer(ids idgen.Generator, publicIDs *idgen.Codec, tokens *token.Maker, sessions *session.Store, relations rpb.RelationsServiceClient) *server {
+	return &server{
+		ids:       ids,
+		publicIDs: publicIDs,
+		tokens:    tokens,
+		sessions:  sessions,
+		relations: relations,
+		users:     make(map[uint64]*user),
+	}
 }
 
 func (s *server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.UserRegisterResponse, error) {
@@ -39,20 +84,100 @@ func (s *server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.Use
 		log.Println(key, md.Get(key))
 	}
 
-	username := req.GetUsername()
-
 	if err := validateRegisterRequest(req); err != nil {
 		return nil, err
 	}
 
-	id := atomic.AddUint64(&idSerial, 1)
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.GetPassword()), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to hash password")
+	}
+
+	id, err := s.ids.NextID()
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to generate user id")
+	}
+
+	now := time.Now()
+	u := &user{
+		id:           id,
+		email:        req.GetEmail(),
+		name:         req.GetName(),
+		username:     req.GetUsername(),
+		description:  req.GetDescription(),
+		passwordHash: passwordHash,
+		createdAt:    now,
+		updatedAt:    now,
+
+		notifications: defaultNotifications,
+	}
 
 	s.mx.Lock()
-	s.users[id] = username
+	for _, existing := range s.users {
+		if strings.EqualFold(existing.email, u.email) {
+			s.mx.Unlock()
+			return nil, status.Error(codes.AlreadyExists, "email is already taken")
+		}
+		if strings.EqualFold(existing.username, u.username) {
+			s.mx.Unlock()
+			return nil, status.Error(codes.AlreadyExists, "username is already taken")
+		}
+	}
+	s.users[u.id] = u
 	s.mx.Unlock()
 
+	accessToken, refreshToken, err := s.startSession(ctx, u.id, req.GetDeviceName())
+	if err != nil {
+		return nil, err
+	}
+
 	return &pb.UserRegisterResponse{
-		Id: id,
+		Id:           s.publicIDs.Encode(u.id),
+		Email:        u.email,
+		Name:         u.name,
+		Username:     u.username,
+		Description:  u.description,
+		CreatedAt:    timestamppb.New(u.createdAt),
+		UpdatedAt:    timestamppb.New(u.updatedAt),
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (s *server) Login(ctx context.Context, req *pb.LoginRequest) (*pb.UserLoginResponse, error) {
+	if req.GetEmail() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	s.mx.RLock()
+	var u *user
+	for _, existing := range s.users {
+		if strings.EqualFold(existing.email, req.GetEmail()) {
+			u = existing
+			break
+		}
+	}
+	s.mx.RUnlock()
+
+	if u == nil || bcrypt.CompareHashAndPassword(u.passwordHash, []byte(req.GetPassword())) != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid email or password")
+	}
+
+	accessToken, refreshToken, err := s.startSession(ctx, u.id, req.GetDeviceName())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.UserLoginResponse{
+		Id:           s.publicIDs.Encode(u.id),
+		Email:        u.email,
+		Name:         u.name,
+		Username:     u.username,
+		Description:  u.description,
+		CreatedAt:    timestamppb.New(u.createdAt),
+		UpdatedAt:    timestamppb.New(u.updatedAt),
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
 	}, nil
 }
 
@@ -174,21 +299,51 @@ func isValidEmail(email string) bool {
 	return strings.Contains(email, "@") && strings.Contains(email, ".")
 }
 
+// GetProfile без id возвращает профиль владельца токена. С id чужого
+// пользователя - только то, что видят другие, как GetUser.
 func (s *server) GetProfile(ctx context.Context, req *pb.GetProfileRequest) (*pb.UserProfile, error) {
+	owner := false
+	id := s.publicIDs.Decode(req.GetId())
+	if _, userID, err := s.authenticate(ctx); err == nil {
+		owner = req.GetId() == 0 || id == userID
+		if req.GetId() == 0 {
+			id = userID
+		}
+	} else if req.GetId() == 0 {
+		return nil, err
+	}
+
 	s.mx.RLock()
 	defer s.mx.RUnlock()
 
-	id := req.GetId()
-	if id == 0 {
-		return nil, status.Error(codes.InvalidArgument, "id is required")
+	u, ok := s.users[id]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
 	}
-
-	return &pb.UserProfile{
-		Id:       id,
-	}, nil
+	if !owner {
+		return s.publicProfile(u), nil
+	}
+	return s.profile(u), nil
 }
 
 func (s *server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.UserProfile, error) {
+	profile, err := s.findUser(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// тех, кого вызвавший пользователь заблокировал, он не находит
+	blocked, err := s.blockedByCaller(ctx, profile.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return profile, nil
+}
+
+func (s *server) findUser(req *pb.GetUserRequest) (*pb.UserProfile, error) {
 	s.mx.RLock()
 	// закрываем блокировку после выполнения функции
 	// чтобы другие горутины могли получить доступ к данным
@@ -197,40 +352,230 @@ func (s *server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.UserP
 
 	// блокировка на чтение позволяет другим горутинам читать данные
 	// блокировка на запись позволяет только одной горутине изменять данные
-	
+
 	defer s.mx.RUnlock()
 
-	id := req.GetId()
-	if id == 0 {
-		return nil, status.Error(codes.InvalidArgument, "id is required")
+	var (
+		u  *user
+		ok bool
+	)
+	switch {
+	case req.GetId() != 0:
+		u, ok = s.users[s.publicIDs.Decode(req.GetId())]
+	case req.GetUsername() != "":
+		u, ok = s.userByUsername(req.GetUsername())
+	default:
+		return nil, status.Error(codes.InvalidArgument, "id or username is required")
 	}
-
-	username, ok := s.users[id]
 	if !ok {
 		return nil, status.Error(codes.NotFound, "user not found")
 	}
 
+	return s.publicProfile(u), nil
+}
+
+// blockedByCaller проверяет в relations, заблокировал ли пользователь,
+// от имени которого пришел вызов через api-gateway, пользователя target.
+// Если relations недоступен, поиск отвечает Unavailable: иначе заблокированный
+// нашелся бы, пока relations лежит.
+func (s *server) blockedByCaller(ctx context.Context, target uint64) (bool, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	caller, err := strconv.ParseUint(firstValue(md, userIDHeader), 10, 64)
+	if err != nil || caller == 0 || caller == target {
+		return false, nil
+	}
+
+	resp, err := s.relations.CheckBlock(ctx, &rpb.CheckBlockRequest{UserId: caller, TargetId: target})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to check blocks", "error", err)
+		return false, status.Error(codes.Unavailable, "failed to check whether the user is blocked")
+	}
+	return resp.GetBlocked(), nil
+}
+
+// maxBatchSize ограничивает число id в одном запросе GetUsers.
+const maxBatchSize = 100
+
+func (s *server) GetUsers(ctx context.Context, req *pb.GetUsersRequest) (*pb.GetUsersResponse, error) {
+	if len(req.GetIds()) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d ids can be requested at once", maxBatchSize)
+	}
+
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	resp := &pb.GetUsersResponse{Users: make([]*pb.UserProfile, 0, len(req.GetIds()))}
+	for _, id := range req.GetIds() {
+		if u, ok := s.users[s.publicIDs.Decode(id)]; ok {
+			resp.Users = append(resp.Users, s.publicProfile(u))
+		}
+	}
+
+	return resp, nil
+}
+
+// publicProfile - то, что о пользователе видят другие пользователи.
+func (s *server) publicProfile(u *user) *pb.UserProfile {
 	return &pb.UserProfile{
-		Id:       id,
-		Username: username,
-	}, nil
+		Id:       s.publicIDs.Encode(u.id),
+		Username: u.username,
+	}
 }
 
+// sessionSweepInterval - как часто из памяти удаляются истекшие сессии.
+const sessionSweepInterval = 10 * time.Minute
+
 func main() {
-	lis, err := net.Listen("tcp", ":8081")
+	defaults := config.Default()
+	defaults.GRPC.Addr = ":8081"
+	defaults.Endpoints.Relations = "localhost:8083"
+
+	cfg, err := config.Load(defaults, os.Args[1:])
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if err := cfg.Require("grpc.addr", "endpoints.relations"); err != nil {
+		log.Fatal(err)
+	}
+	slog.SetLogLoggerLevel(cfg.Log.SlogLevel())
+	log.Printf("config:\n%s", cfg)
+
+	lis, err := net.Listen("tcp", cfg.GRPC.Addr)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	implementation := NewServer() // наша реализация сервера
+	signingKey, err := loadSigningKey(cfg.Tokens.SigningKey)
+	if err != nil {
+		log.Fatalf("failed to load signing key: %v", err)
+	}
+
+	node, err := nodeID(cfg.IDs.Node, cfg.IDs.Dev)
+	if err != nil {
+		log.Fatalf("failed to load node id: %v", err)
+	}
+
+	ids, err := idgen.NewSnowflake(node)
+	if err != nil {
+		log.Fatalf("failed to create id generator: %v", err)
+	}
 
-	server := grpc.NewServer()
+	idKey, err := userIDKey(cfg.IDs.Key, cfg.IDs.Dev)
+	if err != nil {
+		log.Fatalf("failed to load user id key: %v", err)
+	}
+
+	clientCreds := insecure.NewCredentials()
+	if cfg.TLS.CAFile != "" {
+		clientCreds, err = credentials.NewClientTLSFromFile(cfg.TLS.CAFile, "")
+		if err != nil {
+			log.Fatalf("failed to load CA certificate: %v", err)
+		}
+	}
+
+	// relations нужен только чтобы скрывать из поиска заблокированных
+	relationsPolicy := resilience.New("relations", cfg.Backends.Relations)
+	relationsConn, err := grpcpool.New(cfg.Endpoints.Relations, cfg.Endpoints.PoolSize,
+		append(relationsPolicy.DialOptions(), grpc.WithTransportCredentials(clientCreds), servicetoken.Attach(cfg.Tokens.ServiceToken))...)
+	if err != nil {
+		log.Fatalf("failed to create relations client: %v", err)
+	}
+	defer relationsConn.Close()
+
+	sessions := session.NewStore(cfg.Tokens.RefreshTTL, cfg.Tokens.AccessTTL)
+	implementation := NewServer( // наша реализация сервера
+		ids,
+		idgen.NewCodec(idKey),
+		token.NewMaker(signingKey, cfg.Tokens.AccessTTL),
+		sessions,
+		rpb.NewRelationsServiceClient(relationsConn),
+	)
+
+	var opts []grpc.ServerOption
+	if cfg.TLS.Enabled() {
+		creds, err := credentials.NewServerTLSFromFile(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			log.Fatalf("failed to load TLS certificate: %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	// адреса пользователей, ключи подписи и отозванные сессии отдаются
+	// только сервисам с tokens.service_token
+	if cfg.Tokens.ServiceToken == "" {
+		log.Println("tokens.service_token is not set, internal RPCs are refused")
+	}
+	opts = append(opts, grpc.ChainUnaryInterceptor(servicetoken.Require(cfg.Tokens.ServiceToken,
+		pb.AccountsService_GetContacts_FullMethodName,
+		pb.AccountsService_GetSigningKeys_FullMethodName,
+		pb.AccountsService_ListRevokedSessions_FullMethodName,
+	)))
+
+	server := grpc.NewServer(opts...)
 	pb.RegisterAccountsServiceServer(server, implementation) // регистрация обработчиков
 
+	// для readiness проверок: api-gateway и kubernetes спрашивают grpc.health.v1
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+
 	reflection.Register(server) // регистрируем дополнительные обработчики
 
+	ctx, stop := lifecycle.SignalContext()
+	defer stop()
+
+	go sessions.RunSweeper(ctx, sessionSweepInterval)
+
 	log.Printf("server listening at %v", lis.Addr())
-	if err := server.Serve(lis); err != nil {
+	if err := lifecycle.ServeGRPC(ctx, server, lis, cfg.Shutdown.Timeout, healthServer.Shutdown); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
 }
+
+// loadSigningKey разбирает ключ подписи токенов.
+// Если он не задан, генерируется временный ключ.
+func loadSigningKey(seed string) (ed25519.PrivateKey, error) {
+	if seed == "" {
+		log.Println("tokens.signing_key is not set, using a temporary signing key")
+		return token.GenerateKey()
+	}
+	return token.ParseKey(seed)
+}
+
+// nodeID возвращает номер узла для генератора id. У каждой реплики он
+// должен быть свой, поэтому он задается явно. Номер из hash hostname
+// может совпасть у двух реплик, он берется только в режиме ids.dev.
+func nodeID(node uint16, dev bool) (uint16, error) {
+	if node != 0 {
+		return node, nil
+	}
+	if !dev {
+		return 0, errors.New("ids.node is not set: give each replica its own number")
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New32a()
+	h.Write([]byte(hostname))
+	node = uint16(h.Sum32() % (idgen.MaxNode + 1))
+	log.Printf("ids.node is not set, using %d derived from hostname", node)
+
+	return node, nil
+}
+
+// userIDKey возвращает ключ, которым шифруются публичные id пользователей.
+// Он должен совпадать на всех репликах и не меняться, иначе выданные id
+// перестанут находиться. Встроенный ключ публичен, он берется только
+// в режиме ids.dev.
+func userIDKey(key string, dev bool) ([]byte, error) {
+	switch {
+	case key != "":
+	case dev:
+		log.Println("ids.key is not set, using the development key")
+		key = "go_messenger-development-user-id-key"
+	default:
+		return nil, errors.New("ids.key is not set")
+	}
+	return []byte(key), nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+
+	pb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// notificationSettings - какие письма получает пользователь.
+type notificationSettings struct {
+	friendRequests bool
+	friendAccepted bool
+	digest         bool
+}
+
+// defaultNotifications - настройки нового пользователя.
+var defaultNotifications = notificationSettings{friendRequests: true, friendAccepted: true, digest: true}
+
+func (s *server) GetNotificationSettings(ctx context.Context, _ *pb.GetNotificationSettingsRequest) (*pb.NotificationSettings, error) {
+	_, userID, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return u.notifications.toProto(), nil
+}
+
+func (s *server) UpdateNotificationSettings(ctx context.Context, req *pb.NotificationSettings) (*pb.NotificationSettings, error) {
+	_, userID, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	u.notifications = notificationSettings{
+		friendRequests: req.GetFriendRequests(),
+		friendAccepted: req.GetFriendAccepted(),
+		digest:         req.GetDigest(),
+	}
+	return u.notifications.toProto(), nil
+}
+
+func (s *server) GetContacts(ctx context.Context, req *pb.GetContactsRequest) (*pb.GetContactsResponse, error) {
+	if len(req.GetIds()) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d ids can be requested at once", maxBatchSize)
+	}
+
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	resp := &pb.GetContactsResponse{Contacts: make([]*pb.Contact, 0, len(req.GetIds()))}
+	for _, id := range req.GetIds() {
+		if u, ok := s.users[s.publicIDs.Decode(id)]; ok {
+			resp.Contacts = append(resp.Contacts, &pb.Contact{
+				Id:            id,
+				Email:         u.email,
+				Name:          u.name,
+				Notifications: u.notifications.toProto(),
+			})
+		}
+	}
+	return resp, nil
+}
+
+func (n notificationSettings) toProto() *pb.NotificationSettings {
+	return &pb.NotificationSettings{
+		FriendRequests: n.friendRequests,
+		FriendAccepted: n.friendAccepted,
+		Digest:         n.digest,
+	}
+}
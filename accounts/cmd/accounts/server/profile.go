@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	pb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// UpdateProfile меняет непустые поля профиля владельца токена.
+func (s *server) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UpdateProfileResponse, error) {
+	_, userID, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateUpdateProfileRequest(req); err != nil {
+		return nil, err
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	for _, existing := range s.users {
+		if existing.id == u.id {
+			continue
+		}
+		if req.GetEmail() != "" && strings.EqualFold(existing.email, req.GetEmail()) {
+			return nil, status.Error(codes.AlreadyExists, "email is already taken")
+		}
+		if req.GetUsername() != "" && strings.EqualFold(existing.username, req.GetUsername()) {
+			return nil, status.Error(codes.AlreadyExists, "username is already taken")
+		}
+	}
+
+	if req.GetEmail() != "" {
+		u.email = req.GetEmail()
+	}
+	if req.GetName() != "" {
+		u.name = req.GetName()
+	}
+	if req.GetUsername() != "" {
+		u.username = req.GetUsername()
+	}
+	if req.GetDescription() != "" {
+		u.description = req.GetDescription()
+	}
+	u.updatedAt = time.Now()
+
+	return &pb.UpdateProfileResponse{
+		Id:          s.publicIDs.Encode(u.id),
+		Email:       u.email,
+		Name:        u.name,
+		Username:    u.username,
+		Description: u.description,
+		CreatedAt:   timestamppb.New(u.createdAt),
+		UpdatedAt:   timestamppb.New(u.updatedAt),
+	}, nil
+}
+
+func validateUpdateProfileRequest(req *pb.UpdateProfileRequest) error {
+	if req.GetEmail() == "" && req.GetName() == "" && req.GetUsername() == "" && req.GetDescription() == "" {
+		return status.Error(codes.InvalidArgument, "nothing to update")
+	}
+
+	if email := req.GetEmail(); email != "" {
+		if err := validateEmail(email); err != nil {
+			return err
+		}
+	}
+	if name := req.GetName(); name != "" {
+		if err := validateName(name); err != nil {
+			return err
+		}
+	}
+	if username := req.GetUsername(); username != "" {
+		if err := validateUsername(username); err != nil {
+			return err
+		}
+	}
+	if description := req.GetDescription(); description != "" {
+		if err := validateDescription(description); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteProfile удаляет профиль владельца токена и завершает все его сессии.
+// Удалить чужой профиль нельзя.
+func (s *server) DeleteProfile(ctx context.Context, req *pb.DeleteProfileRequest) (*pb.DeleteProfileResponse, error) {
+	_, userID, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetId() != 0 && s.publicIDs.Decode(req.GetId()) != userID {
+		return nil, status.Error(codes.PermissionDenied, "you can only delete your own profile")
+	}
+
+	s.mx.Lock()
+	_, ok := s.users[userID]
+	delete(s.users, userID)
+	s.mx.Unlock()
+
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	s.sessions.RevokeAll(userID)
+
+	return &pb.DeleteProfileResponse{Message: "profile deleted"}, nil
+}
+
+// profile собирает полный профиль для его владельца. Вызывается под s.mx.
+func (s *server) profile(u *user) *pb.UserProfile {
+	return &pb.UserProfile{
+		Id:          s.publicIDs.Encode(u.id),
+		Email:       u.email,
+		Name:        u.name,
+		Username:    u.username,
+		Description: u.description,
+		CreatedAt:   timestamppb.New(u.createdAt),
+		UpdatedAt:   timestamppb.New(u.updatedAt),
+	}
+}
+
+// userByUsername ищет пользователя без учета регистра. Вызывается под s.mx.
+func (s *server) userByUsername(username string) (*user, bool) {
+	for _, u := range s.users {
+		if strings.EqualFold(u.username, username) {
+			return u, true
+		}
+	}
+	return nil, false
+}
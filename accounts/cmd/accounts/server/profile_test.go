@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_messenger/accounts/internal/idgen"
+	"github.com/zura-t/go_messenger/accounts/internal/session"
+	pb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+	"github.com/zura-t/go_messenger/accounts/pkg/token"
+	rpb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestServer(t *testing.T) *server {
+	t.Helper()
+	ids, err := idgen.NewSnowflake(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := token.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(ids, idgen.NewCodec([]byte("test-key")), token.NewMaker(key, time.Minute),
+		session.NewStore(time.Hour, time.Minute), nil)
+}
+
+func register(t *testing.T, s *server, name string) *pb.UserRegisterResponse {
+	t.Helper()
+	resp, err := s.Register(context.Background(), &pb.RegisterRequest{
+		Email:       name + "@example.com",
+		Password:    "secret123!",
+		Username:    name,
+		Name:        name,
+		Description: "hello_there_friends",
+	})
+	if err != nil {
+		t.Fatalf("register %s: %v", name, err)
+	}
+	return resp
+}
+
+func withToken(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestGetProfileHidesOtherUsersEmail(t *testing.T) {
+	s := newTestServer(t)
+	alice := register(t, s, "alice")
+	bob := register(t, s, "bob")
+
+	other, err := s.GetProfile(withToken(alice.GetAccessToken()), &pb.GetProfileRequest{Id: bob.GetId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.GetEmail() != "" || other.GetDescription() != "" || other.GetCreatedAt() != nil {
+		t.Errorf("profile of another user has private fields: %v", other)
+	}
+	if other.GetUsername() != "bob" {
+		t.Errorf("username = %q, want bob", other.GetUsername())
+	}
+
+	anonymous, err := s.GetProfile(context.Background(), &pb.GetProfileRequest{Id: bob.GetId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if anonymous.GetEmail() != "" {
+		t.Errorf("anonymous caller got email %q", anonymous.GetEmail())
+	}
+
+	for _, id := range []uint64{0, alice.GetId()} {
+		own, err := s.GetProfile(withToken(alice.GetAccessToken()), &pb.GetProfileRequest{Id: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if own.GetEmail() != "alice@example.com" {
+			t.Errorf("own profile (id %d) email = %q, want alice@example.com", id, own.GetEmail())
+		}
+	}
+}
+
+// fakeRelations отвечает на CheckBlock: blocked или err.
+type fakeRelations struct {
+	rpb.RelationsServiceClient
+	blocked bool
+	err     error
+}
+
+func (f fakeRelations) CheckBlock(context.Context, *rpb.CheckBlockRequest, ...grpc.CallOption) (*rpb.CheckBlockResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &rpb.CheckBlockResponse{Blocked: f.blocked}, nil
+}
+
+func TestGetUserHidesBlockedUsers(t *testing.T) {
+	tests := []struct {
+		name      string
+		relations fakeRelations
+		anonymous bool
+		want      codes.Code
+	}{
+		{"not blocked", fakeRelations{}, false, codes.OK},
+		{"blocked by the caller", fakeRelations{blocked: true}, false, codes.NotFound},
+		{"relations unavailable", fakeRelations{err: errors.New("connection refused")}, false, codes.Unavailable},
+		{"anonymous caller", fakeRelations{err: errors.New("not called")}, true, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			s.relations = tt.relations
+			alice := register(t, s, "alice")
+			register(t, s, "bob")
+
+			ctx := context.Background()
+			if !tt.anonymous {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(userIDHeader, strconv.FormatUint(alice.GetId(), 10)))
+			}
+			_, err := s.GetUser(ctx, &pb.GetUserRequest{Username: "bob"})
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("code = %v, want %v (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
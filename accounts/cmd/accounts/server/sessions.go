@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/zura-t/go_messenger/accounts/internal/session"
+	pb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+	"github.com/zura-t/go_messenger/accounts/pkg/token"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Заголовки, в которых api-gateway передает данные исходного HTTP клиента.
+const (
+	forwardedForHeader       = "x-forwarded-for"
+	forwardedUserAgentHeader = "x-forwarded-user-agent"
+	// userIDHeader - публичный id пользователя, проверенный по его access токену.
+	userIDHeader = "x-user-id"
+)
+
+// startSession создает сессию для устройства клиента и выпускает пару токенов.
+// В токен попадает публичный id пользователя.
+func (s *server) startSession(ctx context.Context, userID uint64, deviceName string) (string, string, error) {
+	device := clientDevice(ctx)
+	device.Name = deviceName
+
+	sess, refreshToken, err := s.sessions.Create(userID, device)
+	if err != nil {
+		return "", "", status.Error(codes.Internal, "failed to create session")
+	}
+
+	accessToken, err := s.tokens.CreateAccessToken(s.publicIDs.Encode(userID), sess.ID)
+	if err != nil {
+		return "", "", status.Error(codes.Internal, "failed to create access token")
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+func (s *server) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh_token is required")
+	}
+
+	sess, refreshToken, err := s.sessions.Rotate(req.GetRefreshToken(), clientDevice(ctx))
+	if errors.Is(err, session.ErrNotFound) || errors.Is(err, session.ErrExpired) {
+		return nil, status.Error(codes.Unauthenticated, "refresh token is invalid or expired")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to refresh session")
+	}
+
+	accessToken, err := s.tokens.CreateAccessToken(s.publicIDs.Encode(sess.UserID), sess.ID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to create access token")
+	}
+
+	return &pb.RefreshTokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// Logout завершает сессию, к которой относится access токен.
+func (s *server) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	claims, userID, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sessions.Revoke(userID, claims.SessionID); err != nil {
+		return nil, status.Error(codes.NotFound, "session not found")
+	}
+
+	return &pb.LogoutResponse{Message: "logged out"}, nil
+}
+
+func (s *server) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	claims, userID, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := s.sessions.List(userID)
+
+	resp := &pb.ListSessionsResponse{Sessions: make([]*pb.Session, 0, len(sessions))}
+	for _, sess := range sessions {
+		resp.Sessions = append(resp.Sessions, &pb.Session{
+			Id:         sess.ID,
+			DeviceName: sess.Device.Name,
+			UserAgent:  sess.Device.UserAgent,
+			Ip:         sess.Device.IP,
+			CreatedAt:  timestamppb.New(sess.CreatedAt),
+			LastUsedAt: timestamppb.New(sess.LastUsedAt),
+			Current:    sess.ID == claims.SessionID,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *server) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	_, userID, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if err := s.sessions.Revoke(userID, req.GetId()); err != nil {
+		return nil, status.Error(codes.NotFound, "session not found")
+	}
+
+	return &pb.RevokeSessionResponse{Message: "session revoked"}, nil
+}
+
+// GetSigningKeys отдает публичные ключи, которыми подписаны access токены.
+func (s *server) GetSigningKeys(ctx context.Context, req *pb.GetSigningKeysRequest) (*pb.GetSigningKeysResponse, error) {
+	id, key := s.tokens.PublicKey()
+
+	return &pb.GetSigningKeysResponse{
+		Keys: []*pb.SigningKey{{
+			Id:        id,
+			Algorithm: token.Algorithm,
+			PublicKey: key,
+		}},
+	}, nil
+}
+
+// ListRevokedSessions отдает сессии, отозванные начиная с since, пока
+// выпущенные для них access токены еще не истекли.
+func (s *server) ListRevokedSessions(ctx context.Context, req *pb.ListRevokedSessionsRequest) (*pb.ListRevokedSessionsResponse, error) {
+	var since time.Time
+	if req.GetSince() != nil {
+		since = req.GetSince().AsTime()
+	}
+
+	revoked := s.sessions.Revoked(since)
+
+	resp := &pb.ListRevokedSessionsResponse{Sessions: make([]*pb.RevokedSession, 0, len(revoked))}
+	for _, r := range revoked {
+		resp.Sessions = append(resp.Sessions, &pb.RevokedSession{
+			Id:        r.SessionID,
+			RevokedAt: timestamppb.New(r.RevokedAt),
+			ExpiresAt: timestamppb.New(r.ExpiresAt),
+		})
+	}
+
+	return resp, nil
+}
+
+// authenticate проверяет access токен из заголовка authorization
+// и то, что его сессия не была отозвана. Возвращает внутренний id пользователя.
+func (s *server) authenticate(ctx context.Context) (*token.Claims, uint64, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	raw, ok := strings.CutPrefix(firstValue(md, "authorization"), "Bearer ")
+	if !ok || raw == "" {
+		return nil, 0, status.Error(codes.Unauthenticated, "access token is required")
+	}
+
+	claims, err := s.tokens.VerifyAccessToken(raw)
+	if err != nil {
+		return nil, 0, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	userID, err := claims.UserID()
+	if err != nil || !s.sessions.Active(claims.SessionID) {
+		return nil, 0, status.Error(codes.Unauthenticated, "session has been revoked")
+	}
+
+	return claims, s.publicIDs.Decode(userID), nil
+}
+
+// clientDevice собирает user agent и IP клиента. Если запрос пришел через
+// api-gateway, берутся проброшенные им значения.
+func clientDevice(ctx context.Context) session.Device {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	device := session.Device{
+		UserAgent: firstValue(md, forwardedUserAgentHeader),
+		IP:        strings.TrimSpace(strings.Split(firstValue(md, forwardedForHeader), ",")[0]),
+	}
+	if device.UserAgent == "" {
+		device.UserAgent = firstValue(md, "user-agent")
+	}
+	if device.IP == "" {
+		if p, ok := peer.FromContext(ctx); ok {
+			if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+				device.IP = host
+			}
+		}
+	}
+
+	return device
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+package idgen
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+const feistelRounds = 4
+
+// Codec переводит внутренние id во внешние и обратно. Внешний id - это
+// перестановка внутреннего по секретному ключу: он остается uint64, но по
+// нему нельзя восстановить порядок регистрации или перебрать соседние id.
+type Codec struct {
+	key []byte
+}
+
+func NewCodec(key []byte) *Codec {
+	return &Codec{key: key}
+}
+
+// Encode возвращает публичный id для внутреннего.
+func (c *Codec) Encode(id uint64) uint64 {
+	left, right := uint32(id>>32), uint32(id)
+	for round := 0; round < feistelRounds; round++ {
+		left, right = right, left^c.round(round, right)
+	}
+	return uint64(left)<<32 | uint64(right)
+}
+
+// Decode возвращает внутренний id для публичного.
+func (c *Codec) Decode(id uint64) uint64 {
+	left, right := uint32(id>>32), uint32(id)
+	for round := feistelRounds - 1; round >= 0; round-- {
+		left, right = right^c.round(round, left), left
+	}
+	return uint64(left)<<32 | uint64(right)
+}
+
+func (c *Codec) round(round int, half uint32) uint32 {
+	var buf [5]byte
+	buf[0] = byte(round)
+	binary.BigEndian.PutUint32(buf[1:], half)
+
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(buf[:])
+	return binary.BigEndian.Uint32(mac.Sum(nil))
+}
@@ -0,0 +1,59 @@
+package idgen
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	c := NewCodec([]byte("test key"))
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	ids := []uint64{0, 1, 2, math.MaxUint32, math.MaxUint32 + 1, math.MaxUint64 - 1, math.MaxUint64}
+	for i := 0; i < 2000; i++ {
+		ids = append(ids, rng.Uint64())
+	}
+	for _, id := range ids {
+		if got := c.Decode(c.Encode(id)); got != id {
+			t.Fatalf("Decode(Encode(%d)) = %d", id, got)
+		}
+		// Encode - перестановка: у любого публичного id есть внутренний
+		if got := c.Encode(c.Decode(id)); got != id {
+			t.Fatalf("Encode(Decode(%d)) = %d", id, got)
+		}
+	}
+}
+
+func TestCodecBijection(t *testing.T) {
+	c := NewCodec([]byte("test key"))
+
+	// соседние внутренние id не должны давать одинаковые или соседние публичные
+	const n = 20000
+	seen := make(map[uint64]uint64, n)
+	last := c.Encode(0)
+	for id := uint64(1); id <= n; id++ {
+		pub := c.Encode(id)
+		if prev, ok := seen[pub]; ok {
+			t.Fatalf("ids %d and %d both encode to %d", prev, id, pub)
+		}
+		seen[pub] = id
+		if pub == id || pub == last+1 {
+			t.Fatalf("id %d encodes to %d: order is not hidden", id, pub)
+		}
+		last = pub
+	}
+}
+
+func TestCodecKey(t *testing.T) {
+	a, b := NewCodec([]byte("key a")), NewCodec([]byte("key b"))
+	same := 0
+	for id := uint64(1); id <= 1000; id++ {
+		if a.Encode(id) == b.Encode(id) {
+			same++
+		}
+	}
+	if same > 0 {
+		t.Errorf("%d of 1000 ids encode the same with different keys", same)
+	}
+}
@@ -0,0 +1,71 @@
+package idgen
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+
+	MaxNode     = 1<<nodeBits - 1
+	maxSequence = 1<<sequenceBits - 1
+)
+
+// epoch - точка отсчета времени в id, 2024-01-01 UTC.
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var ErrClockMovedBackwards = errors.New("clock moved backwards")
+
+// Generator выдает уникальные id пользователей.
+type Generator interface {
+	NextID() (uint64, error)
+}
+
+// Snowflake собирает id из 41 бита миллисекунд с epoch, 10 бит номера узла
+// и 12 бит счетчика внутри миллисекунды. Id растут со временем и не
+// пересекаются между узлами с разными номерами.
+type Snowflake struct {
+	node uint64
+	now  func() time.Time
+
+	mx       sync.Mutex
+	lastTime int64
+	sequence uint64
+}
+
+func NewSnowflake(node uint16) (*Snowflake, error) {
+	if node > MaxNode {
+		return nil, fmt.Errorf("node must be between 0 and %d, got %d", MaxNode, node)
+	}
+	return &Snowflake{node: uint64(node), now: time.Now}, nil
+}
+
+func (s *Snowflake) NextID() (uint64, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	ms := s.now().Sub(epoch).Milliseconds()
+	if ms < s.lastTime {
+		return 0, ErrClockMovedBackwards
+	}
+
+	if ms == s.lastTime {
+		s.sequence = (s.sequence + 1) & maxSequence
+		if s.sequence == 0 {
+			// счетчик исчерпан, ждем следующую миллисекунду
+			for ms <= s.lastTime {
+				time.Sleep(100 * time.Microsecond)
+				ms = s.now().Sub(epoch).Milliseconds()
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.lastTime = ms
+
+	return uint64(ms)<<(nodeBits+sequenceBits) | s.node<<sequenceBits | s.sequence, nil
+}
@@ -0,0 +1,153 @@
+package idgen
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clock - время для Snowflake, которым управляет тест.
+type clock struct {
+	mx sync.Mutex
+	t  time.Time
+	// step - на сколько время уходит вперед при каждом чтении
+	step time.Duration
+}
+
+func (c *clock) now() time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	t := c.t
+	c.t = c.t.Add(c.step)
+	return t
+}
+
+func (c *clock) set(t time.Time) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.t = t
+}
+
+func newTestSnowflake(t *testing.T, node uint16, c *clock) *Snowflake {
+	t.Helper()
+	s, err := NewSnowflake(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = c.now
+	return s
+}
+
+func TestNewSnowflakeNodeRange(t *testing.T) {
+	if _, err := NewSnowflake(MaxNode); err != nil {
+		t.Errorf("node %d: %v", MaxNode, err)
+	}
+	if _, err := NewSnowflake(MaxNode + 1); err == nil {
+		t.Errorf("node %d: want error", MaxNode+1)
+	}
+}
+
+func TestSnowflakeMonotonic(t *testing.T) {
+	// за одну миллисекунду выдается больше id, чем вмещает счетчик:
+	// генератор должен дождаться следующей миллисекунды
+	c := &clock{t: epoch.Add(time.Hour), step: time.Millisecond / (maxSequence + 2)}
+	s := newTestSnowflake(t, 7, c)
+
+	var last uint64
+	for i := 0; i < 5*maxSequence; i++ {
+		id, err := s.NextID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= last {
+			t.Fatalf("id %d after %d: ids must grow", id, last)
+		}
+		if node := id >> sequenceBits & MaxNode; node != 7 {
+			t.Fatalf("id %d has node %d, want 7", id, node)
+		}
+		last = id
+	}
+}
+
+func TestSnowflakeUniqueConcurrent(t *testing.T) {
+	s, err := NewSnowflake(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, perWorker = 8, 2000
+	results := make([][]uint64, workers)
+	var wg sync.WaitGroup
+	for w := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				id, err := s.NextID()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				results[w] = append(results[w], id)
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[uint64]bool, workers*perWorker)
+	for _, ids := range results {
+		for _, id := range ids {
+			if seen[id] {
+				t.Fatalf("id %d issued twice", id)
+			}
+			seen[id] = true
+		}
+	}
+}
+
+func TestSnowflakeNodesDoNotCollide(t *testing.T) {
+	at := epoch.Add(time.Hour)
+	a := newTestSnowflake(t, 1, &clock{t: at})
+	b := newTestSnowflake(t, 2, &clock{t: at})
+
+	seen := make(map[uint64]bool)
+	for i := 0; i < 100; i++ {
+		for _, s := range []*Snowflake{a, b} {
+			id, err := s.NextID()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if seen[id] {
+				t.Fatalf("id %d issued by two nodes", id)
+			}
+			seen[id] = true
+		}
+	}
+}
+
+func TestSnowflakeClockMovedBackwards(t *testing.T) {
+	at := epoch.Add(time.Hour)
+	c := &clock{t: at}
+	s := newTestSnowflake(t, 1, c)
+
+	first, err := s.NextID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.set(at.Add(-time.Second))
+	if _, err := s.NextID(); !errors.Is(err, ErrClockMovedBackwards) {
+		t.Fatalf("err = %v, want %v", err, ErrClockMovedBackwards)
+	}
+
+	// когда часы догоняют, id снова выдаются и продолжают расти
+	c.set(at)
+	next, err := s.NextID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next <= first {
+		t.Errorf("id %d after %d: ids must grow", next, first)
+	}
+}
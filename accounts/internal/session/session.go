@@ -0,0 +1,294 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("session not found")
+	ErrExpired  = errors.New("session has expired")
+)
+
+// Device - информация о клиенте, с которого выполнен вход.
+type Device struct {
+	Name      string
+	UserAgent string
+	IP        string
+}
+
+// Session соответствует одному выданному refresh токену.
+type Session struct {
+	ID         string
+	UserID     uint64
+	Device     Device
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+
+	tokenHash [sha256.Size]byte
+}
+
+// Revocation - запись об отозванной сессии. Access токены сессии
+// перестают приниматься сразу, а запись нужна до ExpiresAt - пока
+// не истекут все выпущенные для нее токены.
+type Revocation struct {
+	SessionID string
+	RevokedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Store хранит сессии в памяти.
+type Store struct {
+	ttl       time.Duration
+	accessTTL time.Duration
+
+	mx       sync.RWMutex
+	sessions map[string]*Session
+	byToken  map[[sha256.Size]byte]string
+	revoked  []Revocation
+}
+
+// NewStore создает хранилище сессий с временем жизни ttl. accessTTL - срок
+// действия access токенов, столько хранятся записи об отзыве сессий.
+func NewStore(ttl, accessTTL time.Duration) *Store {
+	return &Store{
+		ttl:       ttl,
+		accessTTL: accessTTL,
+		sessions:  make(map[string]*Session),
+		byToken:   make(map[[sha256.Size]byte]string),
+	}
+}
+
+// Create заводит новую сессию и возвращает ее вместе с refresh токеном.
+// Сам токен не хранится, только его хеш.
+func (s *Store) Create(userID uint64, device Device) (Session, string, error) {
+	id, err := newID()
+	if err != nil {
+		return Session{}, "", err
+	}
+	refreshToken, err := newToken()
+	if err != nil {
+		return Session{}, "", err
+	}
+
+	now := time.Now()
+	sess := &Session{
+		ID:         id,
+		UserID:     userID,
+		Device:     device,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.ttl),
+		tokenHash:  sha256.Sum256([]byte(refreshToken)),
+	}
+
+	s.mx.Lock()
+	s.sessions[id] = sess
+	s.byToken[sess.tokenHash] = id
+	s.mx.Unlock()
+
+	return *sess, refreshToken, nil
+}
+
+// Rotate обменивает refresh токен на новый в рамках той же сессии
+// и обновляет время последнего использования.
+func (s *Store) Rotate(refreshToken string, device Device) (Session, string, error) {
+	token, err := newToken()
+	if err != nil {
+		return Session{}, "", err
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	hash := sha256.Sum256([]byte(refreshToken))
+	id, ok := s.byToken[hash]
+	if !ok {
+		return Session{}, "", ErrNotFound
+	}
+	sess := s.sessions[id]
+
+	now := time.Now()
+	if now.After(sess.ExpiresAt) {
+		s.deleteLocked(sess)
+		return Session{}, "", ErrExpired
+	}
+
+	delete(s.byToken, hash)
+	sess.tokenHash = sha256.Sum256([]byte(token))
+	s.byToken[sess.tokenHash] = id
+	sess.LastUsedAt = now
+	sess.ExpiresAt = now.Add(s.ttl)
+	if device.UserAgent != "" {
+		sess.Device.UserAgent = device.UserAgent
+	}
+	if device.IP != "" {
+		sess.Device.IP = device.IP
+	}
+
+	return *sess, token, nil
+}
+
+// Active сообщает, что сессия существует и не истекла.
+func (s *Store) Active(id string) bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	sess, ok := s.sessions[id]
+	return ok && time.Now().Before(sess.ExpiresAt)
+}
+
+// List возвращает активные сессии пользователя, последние использованные - первыми.
+func (s *Store) List(userID uint64) []Session {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	now := time.Now()
+	var list []Session
+	for _, sess := range s.sessions {
+		if sess.UserID == userID && now.Before(sess.ExpiresAt) {
+			list = append(list, *sess)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LastUsedAt.After(list[j].LastUsedAt)
+	})
+
+	return list
+}
+
+// Revoke удаляет сессию пользователя, после чего ее refresh токен
+// и выпущенные для нее access токены перестают приниматься.
+func (s *Store) Revoke(userID uint64, id string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok || sess.UserID != userID {
+		return ErrNotFound
+	}
+	s.revokeLocked(sess)
+
+	return nil
+}
+
+// RevokeAll удаляет все сессии пользователя, например при удалении профиля.
+func (s *Store) RevokeAll(userID uint64) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for _, sess := range s.sessions {
+		if sess.UserID == userID {
+			s.revokeLocked(sess)
+		}
+	}
+}
+
+// Revoked возвращает сессии, отозванные не раньше since, у которых еще
+// могут быть действующие access токены. Так другие сервисы узнают об
+// отзыве, не обращаясь к accounts на каждый запрос.
+func (s *Store) Revoked(since time.Time) []Revocation {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.pruneRevokedLocked(time.Now())
+
+	var list []Revocation
+	for _, r := range s.revoked {
+		if !r.RevokedAt.Before(since) {
+			list = append(list, r)
+		}
+	}
+
+	return list
+}
+
+// DeleteExpired удаляет истекшие сессии и устаревшие записи об отзыве
+// и возвращает, сколько сессий удалено. Refresh токены истекших сессий
+// и так не принимаются, удаление освобождает память.
+func (s *Store) DeleteExpired() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := time.Now()
+	n := 0
+	for _, sess := range s.sessions {
+		if !now.Before(sess.ExpiresAt) {
+			s.deleteLocked(sess)
+			n++
+		}
+	}
+	s.pruneRevokedLocked(now)
+
+	return n
+}
+
+// RunSweeper удаляет истекшие сессии каждые interval и возвращается,
+// когда ctx отменен.
+func (s *Store) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if n := s.DeleteExpired(); n > 0 {
+			slog.InfoContext(ctx, "deleted expired sessions", "count", n)
+		}
+	}
+}
+
+// pruneRevokedLocked забывает отзывы, access токены которых уже истекли.
+func (s *Store) pruneRevokedLocked(now time.Time) {
+	live := s.revoked[:0]
+	for _, r := range s.revoked {
+		if now.Before(r.ExpiresAt) {
+			live = append(live, r)
+		}
+	}
+	s.revoked = live
+}
+
+func (s *Store) revokeLocked(sess *Session) {
+	now := time.Now()
+	s.revoked = append(s.revoked, Revocation{
+		SessionID: sess.ID,
+		RevokedAt: now,
+		ExpiresAt: now.Add(s.accessTTL),
+	})
+	s.deleteLocked(sess)
+}
+
+func (s *Store) deleteLocked(sess *Session) {
+	delete(s.byToken, sess.tokenHash)
+	delete(s.sessions, sess.ID)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
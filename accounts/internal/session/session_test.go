@@ -0,0 +1,76 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeleteExpired(t *testing.T) {
+	s := NewStore(20*time.Millisecond, 20*time.Millisecond)
+	old, oldToken, err := s.Create(1, Device{Name: "phone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, _, err := s.Create(1, Device{Name: "laptop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Revoke(1, revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.DeleteExpired(); n != 0 {
+		t.Fatalf("deleted %d live sessions", n)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	fresh, _, err := s.Create(2, Device{Name: "tablet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := s.DeleteExpired(); n != 1 {
+		t.Fatalf("deleted %d sessions, want 1", n)
+	}
+	if s.Active(old.ID) || !s.Active(fresh.ID) {
+		t.Fatal("only the expired session must be deleted")
+	}
+	if _, _, err := s.Rotate(oldToken, Device{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Rotate: err = %v, want %v", err, ErrNotFound)
+	}
+	if len(s.revoked) != 0 {
+		t.Fatalf("%d revocations kept after their access tokens expired", len(s.revoked))
+	}
+}
+
+func TestRunSweeper(t *testing.T) {
+	s := NewStore(time.Millisecond, time.Millisecond)
+	if _, _, err := s.Create(1, Device{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.RunSweeper(ctx, 5*time.Millisecond)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mx.RLock()
+		n := len(s.sessions)
+		s.mx.RUnlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("sweeper did not delete the expired session")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,142 @@
+// Package token выпускает и проверяет access токены accounts.
+// Проверять токены можно и вне accounts - по публичным ключам подписи,
+// которые сервис отдает через GetSigningKeys.
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const issuer = "go_messenger/accounts"
+
+// Algorithm - алгоритм подписи токенов в терминах JWT.
+const Algorithm = "EdDSA"
+
+var (
+	ErrInvalidToken = errors.New("token is invalid")
+	ErrExpiredToken = errors.New("token has expired")
+	ErrUnknownKey   = errors.New("token is signed with an unknown key")
+)
+
+// Claims - полезная нагрузка access токена.
+type Claims struct {
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// UserID возвращает id пользователя из поля sub.
+func (c *Claims) UserID() (uint64, error) {
+	return strconv.ParseUint(c.Subject, 10, 64)
+}
+
+// Maker выпускает и проверяет access токены, подписанные ключом Ed25519.
+type Maker struct {
+	key       ed25519.PrivateKey
+	keyID     string
+	accessTTL time.Duration
+}
+
+func NewMaker(key ed25519.PrivateKey, accessTTL time.Duration) *Maker {
+	return &Maker{
+		key:       key,
+		keyID:     KeyID(key.Public().(ed25519.PublicKey)),
+		accessTTL: accessTTL,
+	}
+}
+
+// KeyID возвращает идентификатор ключа для заголовка kid - отпечаток
+// публичного ключа, одинаковый на всех репликах с одним ключом.
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// PublicKey возвращает ключ, которым проверяются выпущенные токены.
+func (m *Maker) PublicKey() (string, ed25519.PublicKey) {
+	return m.keyID, m.key.Public().(ed25519.PublicKey)
+}
+
+// ParseKey декодирует seed ключа Ed25519 из base64.
+func ParseKey(seed string) (ed25519.PrivateKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil {
+		return nil, fmt.Errorf("decode signing key: %w", err)
+	}
+	if len(raw) != ed25519.SeedSize {
+		return nil, fmt.Errorf("signing key must be %d bytes, got %d", ed25519.SeedSize, len(raw))
+	}
+	return ed25519.NewKeyFromSeed(raw), nil
+}
+
+// GenerateKey создает случайный ключ, пригодный только для локального запуска:
+// токены перестают быть валидными после рестарта.
+func GenerateKey() (ed25519.PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	return key, err
+}
+
+func (m *Maker) CreateAccessToken(userID uint64, sessionID string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.FormatUint(userID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.accessTTL)),
+		},
+	}
+
+	t := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	t.Header["kid"] = m.keyID
+
+	return t.SignedString(m.key)
+}
+
+func (m *Maker) VerifyAccessToken(raw string) (*Claims, error) {
+	keyID, key := m.PublicKey()
+	return Verify(raw, func(kid string) (ed25519.PublicKey, bool) {
+		return key, kid == keyID
+	})
+}
+
+// Keys ищет публичный ключ подписи по kid.
+type Keys func(kid string) (ed25519.PublicKey, bool)
+
+// Verify проверяет подпись, издателя и срок действия токена.
+// Токены без kid или с неизвестным kid считаются невалидными.
+func Verify(raw string, keys Keys) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := keys(kid)
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		return key, nil
+	},
+		jwt.WithValidMethods([]string{Algorithm}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrExpiredToken
+	}
+	if errors.Is(err, ErrUnknownKey) {
+		return nil, ErrUnknownKey
+	}
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
@@ -1,40 +1,180 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
-	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
+	"github.com/redis/go-redis/v9"
+	pb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+	"github.com/zura-t/go_messenger/api-gateway/internal/auth"
+	"github.com/zura-t/go_messenger/api-gateway/internal/cache"
+	"github.com/zura-t/go_messenger/api-gateway/internal/ratelimit"
+	cpb "github.com/zura-t/go_messenger/chat/pkg/chat"
+	"github.com/zura-t/go_messenger/platform/config"
+	"github.com/zura-t/go_messenger/platform/grpcpool"
+	"github.com/zura-t/go_messenger/platform/health"
+	"github.com/zura-t/go_messenger/platform/lifecycle"
+	"github.com/zura-t/go_messenger/platform/resilience"
+	"github.com/zura-t/go_messenger/platform/servicetoken"
+	rpb "github.com/zura-t/go_messenger/relations/pkg/relations"
+	"github.com/zura-t/go_messenger/relations/pkg/watch"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
-	e := echo.New()
+	defaults := config.Default()
+	defaults.HTTP.Addr = ":8080"
+	defaults.Metrics.Addr = ":9090"
+	defaults.Endpoints.Accounts = "localhost:8081"
+	defaults.Endpoints.Relations = "localhost:8083"
+	defaults.Endpoints.Chat = "localhost:8084"
 
-	e.Use(middleware.Logger())
-	e.Use(middleware.Recover())
+	cfg, err := config.Load(defaults, os.Args[1:])
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if err := cfg.Require("http.addr", "endpoints.accounts", "endpoints.relations", "endpoints.chat", "tokens.service_token", "metrics.addr"); err != nil {
+		log.Fatal(err)
+	}
+	slog.SetLogLoggerLevel(cfg.Log.SlogLevel())
+	log.Printf("config:\n%s", cfg)
 
-	// for startup probe
-	e.GET("/health", func(c echo.Context) error {
-		return c.JSON(http.StatusOK, struct{ Status string }{Status: "OK"})
-	})
+	creds := insecure.NewCredentials()
+	if cfg.TLS.CAFile != "" {
+		creds, err = credentials.NewClientTLSFromFile(cfg.TLS.CAFile, "")
+		if err != nil {
+			log.Fatalf("failed to load CA certificate: %v", err)
+		}
+	}
 
-	// for readiness probe
-	e.GET("/ready", func(c echo.Context) error {
-		return c.JSON(http.StatusOK, struct{ Status string }{Status: "OK"})
-	})
+	accountsPolicy := resilience.New("accounts", cfg.Backends.Accounts)
+	accountsConn, err := grpcpool.New(cfg.Endpoints.Accounts, cfg.Endpoints.PoolSize,
+		append(accountsPolicy.DialOptions(), grpc.WithTransportCredentials(creds), servicetoken.Attach(cfg.Tokens.ServiceToken))...)
+	if err != nil {
+		log.Fatalf("failed to create accounts client: %v", err)
+	}
+	defer accountsConn.Close()
+	accounts := pb.NewAccountsServiceClient(accountsConn)
 
-	e.GET("/hello", func(c echo.Context) error {
-		return c.HTML(http.StatusOK, "Hello, Docker!")
-	})
+	relationsPolicy := resilience.New("relations", cfg.Backends.Relations)
+	relationsConn, err := grpcpool.New(cfg.Endpoints.Relations, cfg.Endpoints.PoolSize,
+		append(relationsPolicy.DialOptions(), grpc.WithTransportCredentials(creds), servicetoken.Attach(cfg.Tokens.ServiceToken))...)
+	if err != nil {
+		log.Fatalf("failed to create relations client: %v", err)
+	}
+	defer relationsConn.Close()
+	relations := rpb.NewRelationsServiceClient(relationsConn)
+
+	chatPolicy := resilience.New("chat", cfg.Backends.Chat)
+	chatConn, err := grpcpool.New(cfg.Endpoints.Chat, cfg.Endpoints.PoolSize,
+		append(chatPolicy.DialOptions(), grpc.WithTransportCredentials(creds))...)
+	if err != nil {
+		log.Fatalf("failed to create chat client: %v", err)
+	}
+	defer chatConn.Close()
+
+	limiter, err := newLimiter(cfg)
+	if err != nil {
+		log.Fatalf("failed to configure rate limits: %v", err)
+	}
+
+	authn := auth.New(accounts, cfg.Tokens.SyncInterval)
+
+	checker := health.NewChecker(2 * time.Second)
+	checker.Add("accounts", health.GRPC(accountsConn))
+	checker.Add("accounts.breaker", accountsPolicy.Check)
+	checker.Add("relations", health.GRPC(relationsConn))
+	checker.Add("relations.breaker", relationsPolicy.Check)
+	checker.Add("chat", health.GRPC(chatConn))
+	checker.Add("chat.breaker", chatPolicy.Check)
+	checker.Add("auth", authn.Check)
 
-	httpPort := os.Getenv("PORT")
-	if httpPort == "" {
-		httpPort = "8080"
+	responses := cache.NewMemory(10000)
+	e, err := gateway{
+		accounts:  accounts,
+		relations: relations,
+		chat:      cpb.NewChatServiceClient(chatConn),
+		authn:     authn,
+		limiter:   limiter,
+		responses: responses,
+		checker:   checker,
+		browser:   cfg.Browser,
+		tokens:    cfg.Tokens,
+	}.newServer()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	time.Sleep(5 * time.Second)
+	ctx, stop := lifecycle.SignalContext()
+	defer stop()
 
-	e.Logger.Fatal(e.Start(":" + httpPort))
+	go authn.Run(ctx)
+
+	// метрики отдаются на отдельном адресе, который не публикуется наружу
+	metrics := &http.Server{
+		Addr:              cfg.Metrics.Addr,
+		Handler:           newMetrics(accountsPolicy, relationsPolicy, chatPolicy),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := lifecycle.ServeHTTP(ctx, metrics, cfg.Shutdown.Timeout); err != nil {
+			log.Fatalf("failed to serve metrics: %v", err)
+		}
+	}()
+	go invalidateOnRelations(ctx, relations, responses)
+
+	if err := lifecycle.ServeEcho(ctx, e, cfg, checker.Drain); err != nil {
+		e.Logger.Fatal(err)
+	}
+}
+
+// newLimiter выбирает хранилище лимитов: Redis, если он настроен, чтобы
+// лимиты были общими для всех реплик, иначе память процесса.
+func newLimiter(cfg config.Config) (*ratelimit.Limiter, error) {
+	limits := make(map[string]ratelimit.Limit)
+	for group, raw := range map[string]string{
+		"login":     cfg.RateLimits.Login,
+		"register":  cfg.RateLimits.Register,
+		"chat_send": cfg.RateLimits.ChatSend,
+		"search":    cfg.RateLimits.Search,
+	} {
+		limit, ok, err := ratelimit.ParseLimit(raw)
+		if err != nil {
+			return nil, fmt.Errorf("rate_limits.%s: %w", group, err)
+		}
+		if ok {
+			limits[group] = limit
+		}
+	}
+
+	var backend ratelimit.Backend = ratelimit.NewMemory()
+	if cfg.Endpoints.Redis != "" {
+		backend = ratelimit.NewRedis(redis.NewClient(&redis.Options{Addr: cfg.Endpoints.Redis}), "ratelimit:")
+	}
+
+	return ratelimit.New(backend, rateLimited, limits), nil
+}
+
+// invalidateOnRelations сбрасывает закешированный поиск участников
+// блокировки, в том числе сделанной через другую реплику gateway.
+func invalidateOnRelations(ctx context.Context, relations rpb.RelationsServiceClient, responses *cache.Memory) {
+	watch.Run(ctx, relations, &rpb.WatchRelationsRequest{}, watch.Handler{
+		Event: func(e *rpb.RelationEvent) {
+			switch e.GetType() {
+			case rpb.RelationEventType_RELATION_EVENT_TYPE_USER_BLOCKED,
+				rpb.RelationEventType_RELATION_EVENT_TYPE_USER_UNBLOCKED:
+				responses.InvalidateUser(strconv.FormatUint(e.GetActorId(), 10))
+				responses.InvalidateUser(strconv.FormatUint(e.GetTargetId(), 10))
+			}
+		},
+		Resync: responses.Clear,
+	})
 }
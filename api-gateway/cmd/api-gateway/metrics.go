@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/zura-t/go_messenger/platform/resilience"
+)
+
+// newMetrics отдает на metrics.addr состояние circuit breaker и счетчики
+// вызовов каждого сервиса вместе со стандартными метриками процесса.
+func newMetrics(backends ...*resilience.Client) http.Handler {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	for _, b := range backends {
+		labels := prometheus.Labels{"backend": b.Name()}
+		reg.MustRegister(
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Name:        "gateway_backend_breaker_state",
+				Help:        "Circuit breaker state: 0 closed, 1 half-open, 2 open.",
+				ConstLabels: labels,
+			}, func() float64 { return float64(b.Stats().State) }),
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Name:        "gateway_backend_retries_total",
+				Help:        "Retried calls to the backend.",
+				ConstLabels: labels,
+			}, func() float64 { return float64(b.Stats().Retries) }),
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Name:        "gateway_backend_failures_total",
+				Help:        "Calls that failed because the backend is unavailable or too slow.",
+				ConstLabels: labels,
+			}, func() float64 { return float64(b.Stats().Failures) }),
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Name:        "gateway_backend_rejected_total",
+				Help:        "Calls rejected by the open circuit breaker.",
+				ConstLabels: labels,
+			}, func() float64 { return float64(b.Stats().Rejected) }),
+		)
+	}
+
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
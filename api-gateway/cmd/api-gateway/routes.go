@@ -0,0 +1,130 @@
+package main
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/zura-t/go_messenger/api-gateway/internal/aggregate"
+	"github.com/zura-t/go_messenger/api-gateway/internal/openapi"
+	"github.com/zura-t/go_messenger/platform/health"
+
+	"github.com/zura-t/go_messenger/api-gateway/internal/auth"
+	"github.com/zura-t/go_messenger/api-gateway/internal/browser"
+	"github.com/zura-t/go_messenger/api-gateway/internal/cache"
+	"github.com/zura-t/go_messenger/api-gateway/internal/ratelimit"
+	"github.com/zura-t/go_messenger/api-gateway/internal/version"
+)
+
+// versions - версии API. Пути без версии остались от клиентов, выпущенных
+// до появления версий: до Sunset они обслуживаются как v1.
+// Новая версия добавляется в Versions, а ее маршруты RPC - аннотациями
+// с префиксом /v2; старую версию при этом можно пометить Deprecated.
+var versions = version.Policy{
+	Versions: map[string]version.Lifecycle{
+		"v1": {},
+	},
+	Legacy: "v1",
+	LegacyLifecycle: version.Lifecycle{
+		Deprecated: time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+		Sunset:     time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC),
+		Link:       "/docs",
+	},
+	Unversioned: []string{"/health", "/ready", "/hello", "/openapi.json", "/docs"},
+}
+
+// access объявляет, какие маршруты доступны без access токена.
+// Маршрут, не указанный здесь, не даст gateway запуститься.
+var access = auth.Policy{
+	"GET /health": auth.Public,
+	"GET /ready":  auth.Public,
+	"GET /hello":  auth.Public,
+
+	"GET /openapi.json": auth.Public,
+	"GET /docs":         auth.Public,
+	"GET /docs/*":       auth.Public,
+
+	"POST /v1/register":      auth.Public,
+	"POST /v1/login":         auth.Public,
+	"POST /v1/refresh_token": auth.Public,
+
+	"GET /v1/me/home": auth.Protected,
+
+	"GET /v1/accounts":                 auth.Protected,
+	"GET /v1/accounts/profile":         auth.Protected,
+	"PATCH /v1/accounts/profile":       auth.Protected,
+	"DELETE /v1/accounts/profile":      auth.Protected,
+	"POST /v1/logout":                  auth.Protected,
+	"GET /v1/accounts/sessions":        auth.Protected,
+	"DELETE /v1/accounts/sessions/:id": auth.Protected,
+	"GET /v1/accounts/notifications":   auth.Protected,
+	"PUT /v1/accounts/notifications":   auth.Protected,
+
+	"POST /v1/relations":            auth.Protected,
+	"POST /v1/relations/request":    auth.Protected,
+	"DELETE /v1/relations/:user_id": auth.Protected,
+	"GET /v1/relations":             auth.Protected,
+
+	"GET /v1/relations/suggestions":     auth.Protected,
+	"GET /v1/relations/:user_id/mutual": auth.Protected,
+
+	"POST /v1/relations/blocks":            auth.Protected,
+	"DELETE /v1/relations/blocks/:user_id": auth.Protected,
+	"GET /v1/relations/blocks":             auth.Protected,
+
+	"POST /v1/relations/groups":                              auth.Protected,
+	"GET /v1/relations/groups":                               auth.Protected,
+	"GET /v1/relations/groups/:group_id":                     auth.Protected,
+	"PATCH /v1/relations/groups/:group_id":                   auth.Protected,
+	"DELETE /v1/relations/groups/:group_id":                  auth.Protected,
+	"POST /v1/relations/groups/:group_id/members":            auth.Protected,
+	"DELETE /v1/relations/groups/:group_id/members/:user_id": auth.Protected,
+
+	"POST /v1/chat/message":       auth.Protected,
+	"GET /v1/chat/messages":       auth.Protected,
+	"POST /v1/chat/conversations": auth.Protected,
+	"GET /v1/chat/conversations":  auth.Protected,
+}
+
+// rateLimited относит маршруты к группам лимитов из настроек rate_limits.
+var rateLimited = ratelimit.Routes{
+	"POST /v1/login":        "login",
+	"POST /v1/register":     "register",
+	"POST /v1/chat/message": "chat_send",
+	"GET /v1/accounts":      "search",
+}
+
+// cookieSessions - маршруты, которые выдают и отзывают токены. В режиме
+// browser.cookies gateway переносит токены из их ответов в cookie.
+var cookieSessions = browser.Sessions{
+	Issue:   []string{"POST /v1/register", "POST /v1/login"},
+	Refresh: "POST /v1/refresh_token",
+	Logout:  "POST /v1/logout",
+}
+
+// cached задает, сколько хранятся ответы маршрутов чтения.
+var cached = cache.Routes{
+	"GET /v1/accounts":         30 * time.Second,
+	"GET /v1/accounts/profile": time.Minute,
+}
+
+// profileMutations меняют профиль вызвавшего пользователя или то, каких
+// пользователей он видит; после их успешного ответа сбрасывается кеш
+// этого профиля и ответов, полученных им самим.
+var profileMutations = []string{
+	"PATCH /v1/accounts/profile",
+	"DELETE /v1/accounts/profile",
+	"POST /v1/relations/blocks",
+	"DELETE /v1/relations/blocks/:user_id",
+}
+
+// documented описывает в OpenAPI маршруты, которые обслуживает сам gateway.
+// Маршруты RPC описываются по proto автоматически.
+var documented = map[string]*openapi.Operation{
+	"GET /health":       openapi.Op("Startup probe", struct{ Status string }{}),
+	"GET /ready":        openapi.Op("Readiness probe with the state of every dependency", health.Report{}),
+	"GET /hello":        openapi.Raw("Greeting page", echo.MIMETextHTML),
+	"GET /v1/me/home":   openapi.Op("Profile, friends and last messages of the caller", aggregate.HomePage{}),
+	"GET /openapi.json": openapi.Op("This document", nil),
+	"GET /docs":         openapi.Raw("Swagger UI", echo.MIMETextHTML),
+	"GET /docs/*":       openapi.Raw("Swagger UI assets", echo.MIMEOctetStream),
+}
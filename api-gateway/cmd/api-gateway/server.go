@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	pb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+	"github.com/zura-t/go_messenger/api-gateway/internal/aggregate"
+	"github.com/zura-t/go_messenger/api-gateway/internal/auth"
+	"github.com/zura-t/go_messenger/api-gateway/internal/browser"
+	"github.com/zura-t/go_messenger/api-gateway/internal/cache"
+	"github.com/zura-t/go_messenger/api-gateway/internal/openapi"
+	"github.com/zura-t/go_messenger/api-gateway/internal/ratelimit"
+	"github.com/zura-t/go_messenger/api-gateway/internal/transcode"
+	cpb "github.com/zura-t/go_messenger/chat/pkg/chat"
+	"github.com/zura-t/go_messenger/platform/config"
+	"github.com/zura-t/go_messenger/platform/health"
+	rpb "github.com/zura-t/go_messenger/relations/pkg/relations"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// gateway - зависимости, из которых собирается HTTP сервер.
+type gateway struct {
+	accounts  pb.AccountsServiceClient
+	relations rpb.RelationsServiceClient
+	chat      cpb.ChatServiceClient
+	authn     *auth.Authenticator
+	limiter   *ratelimit.Limiter
+	responses *cache.Memory
+	checker   *health.Checker
+	browser   config.Browser
+	tokens    config.Tokens
+}
+
+// newServer регистрирует middleware и маршруты gateway
+// и описывает их в спецификации /openapi.json.
+func (g gateway) newServer() (*echo.Echo, error) {
+	gwmux := transcode.NewMux()
+	if err := pb.RegisterAccountsServiceHandlerClient(context.Background(), gwmux, g.accounts); err != nil {
+		return nil, fmt.Errorf("register accounts handlers: %w", err)
+	}
+	if err := rpb.RegisterRelationsServiceHandlerClient(context.Background(), gwmux, g.relations); err != nil {
+		return nil, fmt.Errorf("register relations handlers: %w", err)
+	}
+	if err := cpb.RegisterChatServiceHandlerClient(context.Background(), gwmux, g.chat); err != nil {
+		return nil, fmt.Errorf("register chat handlers: %w", err)
+	}
+
+	e := echo.New()
+	// IP клиента для лимитов берется из X-Forwarded-For, только если
+	// запрос пришел от прокси из частной сети.
+	e.IPExtractor = echo.ExtractIPFromXFFHeader()
+
+	e.Pre(middleware.RemoveTrailingSlash())
+	e.Pre(versions.Rewrite())
+	e.Use(middleware.Logger())
+	e.Use(middleware.Recover())
+	e.Use(browser.CORS(g.browser))
+	e.Use(browser.SecurityHeaders(g.browser))
+	e.Use(versions.Middleware())
+	if g.browser.Cookies {
+		sessions := cookieSessions
+		sessions.AccessTTL = g.tokens.AccessTTL
+		sessions.RefreshTTL = g.tokens.RefreshTTL
+		e.Use(sessions.Middleware())
+	}
+	e.Use(g.authn.Middleware(access))
+	e.Use(g.limiter.Middleware())
+	e.Use(cache.New(g.responses, cached, profileMutations...).Middleware())
+
+	// for startup probe
+	e.GET("/health", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, struct{ Status string }{Status: "OK"})
+	})
+
+	// for readiness probe
+	e.GET("/ready", g.checker.Handler)
+
+	e.GET("/hello", func(c echo.Context) error {
+		return c.HTML(http.StatusOK, "Hello, Docker!")
+	})
+
+	home := &aggregate.Home{
+		Relations: aggregate.RelationsClient{Client: g.relations},
+		Chat:      aggregate.ChatClient{Client: g.chat},
+		Accounts:  aggregate.AccountsClient{Client: g.accounts},
+		Deadlines: aggregate.Deadlines{
+			Relations: 300 * time.Millisecond,
+			Chat:      300 * time.Millisecond,
+			Accounts:  300 * time.Millisecond,
+		},
+	}
+	v1 := e.Group("/v1")
+	v1.GET("/me/home", home.Handler)
+
+	spec := openapi.New("go_messenger api-gateway", "1.0.0")
+	for route, op := range documented {
+		method, path, _ := strings.Cut(route, " ")
+		spec.Add(method, path, op)
+	}
+
+	// маршруты сервисов объявлены аннотациями google.api.http в их service.proto
+	for _, sd := range []protoreflect.ServiceDescriptor{
+		pb.File_accounts_service_proto.Services().ByName("AccountsService"),
+		rpb.File_relations_service_proto.Services().ByName("RelationsService"),
+		cpb.File_chat_service_proto.Services().ByName("ChatService"),
+	} {
+		for _, r := range transcode.Mount(e, gwmux, sd) {
+			spec.AddRoute(r)
+		}
+	}
+
+	e.GET("/openapi.json", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, spec)
+	})
+	e.GET("/docs", openapi.UI("/docs", "/openapi.json"))
+	e.GET("/docs/*", openapi.Assets("/docs"))
+
+	if err := access.Validate(e.Routes()); err != nil {
+		return nil, err
+	}
+	for _, r := range e.Routes() {
+		if access[r.Method+" "+r.Path] == auth.Protected {
+			spec.Protect(r.Method, r.Path)
+		}
+		if versions.Deprecated(r.Method + " " + r.Path) {
+			spec.Deprecate(r.Method, r.Path)
+		}
+	}
+
+	return e, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	pb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+	"github.com/zura-t/go_messenger/api-gateway/internal/auth"
+	"github.com/zura-t/go_messenger/api-gateway/internal/cache"
+	"github.com/zura-t/go_messenger/api-gateway/internal/openapi"
+	"github.com/zura-t/go_messenger/api-gateway/internal/ratelimit"
+	cpb "github.com/zura-t/go_messenger/chat/pkg/chat"
+	"github.com/zura-t/go_messenger/platform/health"
+	rpb "github.com/zura-t/go_messenger/relations/pkg/relations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// newTestServer собирает gateway, бэкенды которого недоступны.
+func newTestServer(t *testing.T) *echo.Echo {
+	t.Helper()
+	// соединение не устанавливается, пока по нему не пойдет вызов
+	conn, err := grpc.NewClient("passthrough:///accounts", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	accounts := pb.NewAccountsServiceClient(conn)
+
+	e, err := gateway{
+		accounts:  accounts,
+		relations: rpb.NewRelationsServiceClient(conn),
+		chat:      cpb.NewChatServiceClient(conn),
+		authn:     auth.New(accounts, time.Second),
+		limiter:   ratelimit.New(ratelimit.NewMemory(), nil, nil),
+		responses: cache.NewMemory(10),
+		checker:   health.NewChecker(time.Second),
+	}.newServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// Каждый зарегистрированный маршрут должен быть описан в /openapi.json:
+// RPC - аннотацией google.api.http, остальные - в documented.
+func TestOpenAPICoversAllRoutes(t *testing.T) {
+	e := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status %d", rec.Code)
+	}
+
+	var spec openapi.Document
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+
+	for _, r := range e.Routes() {
+		if spec.Paths[openapi.Path(r.Path)][strings.ToLower(r.Method)] == nil {
+			t.Errorf("route %s %s is missing from /openapi.json", r.Method, r.Path)
+		}
+	}
+}
+
+// Каждая мутация профиля сбрасывает ответы вызвавшего и его профиль,
+// закешированный у других пользователей.
+func TestProfileMutationsInvalidateCache(t *testing.T) {
+	registered := make(map[string]bool)
+	for _, r := range newTestServer(t).Routes() {
+		registered[r.Method+" "+r.Path] = true
+	}
+
+	for _, route := range profileMutations {
+		t.Run(route, func(t *testing.T) {
+			if !registered[route] {
+				t.Fatalf("%s is not a gateway route", route)
+			}
+
+			// маршруты с ответами бэкенда, пользователь - из X-Test-User
+			e := echo.New()
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					ctx := auth.WithUserID(c.Request().Context(), c.Request().Header.Get("X-Test-User"))
+					c.SetRequest(c.Request().WithContext(ctx))
+					return next(c)
+				}
+			})
+			e.Use(cache.New(cache.NewMemory(100), cached, profileMutations...).Middleware())
+			e.GET("/v1/accounts/profile", func(c echo.Context) error {
+				id := c.QueryParam("id")
+				if id == "" {
+					id, _ = auth.UserID(c.Request().Context())
+				}
+				return c.JSON(http.StatusOK, map[string]string{"id": id})
+			})
+			method, path, _ := strings.Cut(route, " ")
+			e.Add(method, path, func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+			get := func(user, target string) string {
+				req := httptest.NewRequest(http.MethodGet, target, nil)
+				req.Header.Set("X-Test-User", user)
+				rec := httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+				return rec.Header().Get("X-Cache")
+			}
+			// свой профиль, его профиль у другого и чужой профиль у другого
+			get("1", "/v1/accounts/profile")
+			get("2", "/v1/accounts/profile?id=1")
+			get("2", "/v1/accounts/profile?id=3")
+
+			req := httptest.NewRequest(method, strings.ReplaceAll(path, ":user_id", "5"), nil)
+			req.Header.Set("X-Test-User", "1")
+			e.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got := get("1", "/v1/accounts/profile"); got != "MISS" {
+				t.Errorf("viewer's own response: X-Cache = %s, want MISS", got)
+			}
+			if got := get("2", "/v1/accounts/profile?id=1"); got != "MISS" {
+				t.Errorf("viewer's profile seen by others: X-Cache = %s, want MISS", got)
+			}
+			if got := get("2", "/v1/accounts/profile?id=3"); got != "HIT" {
+				t.Errorf("unrelated response: X-Cache = %s, want HIT", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Package aggregate собирает ответы gateway из данных нескольких сервисов.
+package aggregate
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/zura-t/go_messenger/api-gateway/internal/auth"
+)
+
+// Сколько друзей и переписок попадает на домашнюю страницу.
+const (
+	maxFriends       = 50
+	maxConversations = 20
+)
+
+// Deadlines - сколько ждать ответа каждого сервиса.
+type Deadlines struct {
+	Relations time.Duration
+	Chat      time.Duration
+	Accounts  time.Duration
+}
+
+// Home собирает домашнюю страницу пользователя: друзей из relations,
+// последние сообщения из chat и профили из accounts.
+type Home struct {
+	Relations Relations
+	Chat      Chat
+	Accounts  Accounts
+	Deadlines Deadlines
+}
+
+// HomePage - ответ GET /me/home. Degraded перечисляет сервисы, которые
+// не ответили вовремя: их разделы пусты или без профилей.
+type HomePage struct {
+	Me            *Profile       `json:"me"`
+	Friends       []Profile      `json:"friends"`
+	Conversations []Conversation `json:"conversations"`
+	Degraded      []string       `json:"degraded"`
+}
+
+// Handler отвечает 200 даже при отказе части сервисов, чтобы клиент
+// показал то, что удалось собрать.
+func (h *Home) Handler(c echo.Context) error {
+	userID, ok := auth.UserID(c.Request().Context())
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "access token is required")
+	}
+	ctx := c.Request().Context()
+
+	page := HomePage{Friends: []Profile{}, Conversations: []Conversation{}, Degraded: []string{}}
+	degrade := func(service string, err error) {
+		c.Logger().Warnf("home: %s: %v", service, err)
+		page.Degraded = append(page.Degraded, service)
+	}
+
+	// друзья и переписки не зависят друг от друга, профили нужны для обоих
+	var (
+		wg                    sync.WaitGroup
+		friendIDs             []string
+		conversations         []Conversation
+		relationsErr, chatErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		ctx, cancel := context.WithTimeout(ctx, h.Deadlines.Relations)
+		defer cancel()
+		friendIDs, relationsErr = h.Relations.FriendIDs(ctx, userID)
+	}()
+	go func() {
+		defer wg.Done()
+		ctx, cancel := context.WithTimeout(ctx, h.Deadlines.Chat)
+		defer cancel()
+		conversations, chatErr = h.Chat.Conversations(ctx, userID)
+	}()
+	wg.Wait()
+
+	if relationsErr != nil {
+		degrade("relations", relationsErr)
+	}
+	if chatErr != nil {
+		degrade("chat", chatErr)
+	}
+
+	friendIDs = truncate(friendIDs, maxFriends)
+	conversations = truncate(conversations, maxConversations)
+
+	ids := []string{userID}
+	ids = append(ids, friendIDs...)
+	for _, conv := range conversations {
+		if conv.PeerID != "" {
+			ids = append(ids, conv.PeerID)
+		}
+	}
+
+	actx, cancel := context.WithTimeout(ctx, h.Deadlines.Accounts)
+	profiles, err := h.Accounts.Profiles(actx, unique(ids))
+	cancel()
+	if err != nil {
+		degrade("accounts", err)
+	}
+
+	if me, ok := profiles[userID]; ok {
+		page.Me = &me
+	}
+	for _, id := range friendIDs {
+		profile, ok := profiles[id]
+		if !ok {
+			profile = Profile{ID: id}
+		}
+		page.Friends = append(page.Friends, profile)
+	}
+	for _, conv := range conversations {
+		if conv.PeerID == "" {
+			page.Conversations = append(page.Conversations, conv)
+			continue
+		}
+		peer, ok := profiles[conv.PeerID]
+		if !ok {
+			peer = Profile{ID: conv.PeerID}
+		}
+		conv.Peer = &peer
+		page.Conversations = append(page.Conversations, conv)
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+func truncate[T any](s []T, n int) []T {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+func unique(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	out := ids[:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,163 @@
+package aggregate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/zura-t/go_messenger/api-gateway/internal/auth"
+)
+
+var errDown = errors.New("service is down")
+
+// source отвечает данными или ошибкой err; с delay - не раньше, чем
+// через delay или по отмене ctx.
+type source struct {
+	err   error
+	delay time.Duration
+}
+
+func (s source) wait(ctx context.Context) error {
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return s.err
+}
+
+type fakeRelations struct{ source }
+
+func (f fakeRelations) FriendIDs(ctx context.Context, _ string) ([]string, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	return []string{"2", "3"}, nil
+}
+
+type fakeChat struct{ source }
+
+func (f fakeChat) Conversations(ctx context.Context, _ string) ([]Conversation, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	return []Conversation{{PeerID: "4", LastMessage: &Message{SenderID: "4", Text: "hi"}}}, nil
+}
+
+type fakeAccounts struct{ source }
+
+func (f fakeAccounts) Profiles(ctx context.Context, ids []string) (map[string]Profile, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	profiles := make(map[string]Profile, len(ids))
+	for _, id := range ids {
+		profiles[id] = Profile{ID: id, Username: "user" + id}
+	}
+	return profiles, nil
+}
+
+func TestHomeDegradesOnlyFailedSections(t *testing.T) {
+	slow := source{delay: time.Second}
+	tests := []struct {
+		name      string
+		relations source
+		chat      source
+		accounts  source
+		degraded  []string
+		// friends и peers - username друзей и собеседников; "" - профиля нет
+		friends []string
+		peers   []string
+	}{
+		{
+			name:     "all services answer",
+			degraded: []string{},
+			friends:  []string{"user2", "user3"},
+			peers:    []string{"user4"},
+		},
+		{
+			name:      "relations fails",
+			relations: source{err: errDown},
+			degraded:  []string{"relations"},
+			friends:   []string{},
+			peers:     []string{"user4"},
+		},
+		{
+			name:     "chat times out",
+			chat:     slow,
+			degraded: []string{"chat"},
+			friends:  []string{"user2", "user3"},
+			peers:    []string{},
+		},
+		{
+			name:     "accounts fails",
+			accounts: source{err: errDown},
+			degraded: []string{"accounts"},
+			friends:  []string{"", ""},
+			peers:    []string{""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Home{
+				Relations: fakeRelations{tt.relations},
+				Chat:      fakeChat{tt.chat},
+				Accounts:  fakeAccounts{tt.accounts},
+				Deadlines: Deadlines{
+					Relations: 50 * time.Millisecond,
+					Chat:      50 * time.Millisecond,
+					Accounts:  50 * time.Millisecond,
+				},
+			}
+			page := getHome(t, h)
+
+			if !slices.Equal(page.Degraded, tt.degraded) {
+				t.Errorf("degraded = %v, want %v", page.Degraded, tt.degraded)
+			}
+			var friends, peers []string
+			for _, f := range page.Friends {
+				friends = append(friends, f.Username)
+			}
+			for _, c := range page.Conversations {
+				peers = append(peers, c.Peer.Username)
+			}
+			if !slices.Equal(friends, tt.friends) {
+				t.Errorf("friends = %q, want %q", friends, tt.friends)
+			}
+			if !slices.Equal(peers, tt.peers) {
+				t.Errorf("conversation peers = %q, want %q", peers, tt.peers)
+			}
+			// профиль самого пользователя зависит только от accounts
+			if (page.Me == nil) != (tt.accounts.err != nil) {
+				t.Errorf("me = %v", page.Me)
+			}
+		})
+	}
+}
+
+func getHome(t *testing.T, h *Home) HomePage {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/me/home", nil)
+	req = req.WithContext(auth.WithUserID(req.Context(), "1"))
+	rec := httptest.NewRecorder()
+	if err := h.Handler(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var page HomePage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	return page
+}
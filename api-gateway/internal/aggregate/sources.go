@@ -0,0 +1,136 @@
+package aggregate
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	pb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+	cpb "github.com/zura-t/go_messenger/chat/pkg/chat"
+	rpb "github.com/zura-t/go_messenger/relations/pkg/relations"
+	"google.golang.org/grpc/metadata"
+)
+
+type Profile struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type Message struct {
+	SenderID string    `json:"sender_id"`
+	Text     string    `json:"text"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+// Conversation - личная переписка с Peer или групповая с ID и Title
+// с последним сообщением в ней.
+type Conversation struct {
+	ID          string   `json:"id,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	PeerID      string   `json:"-"`
+	Peer        *Profile `json:"peer,omitempty"`
+	LastMessage *Message `json:"last_message,omitempty"`
+	ReadOnly    bool     `json:"read_only,omitempty"`
+}
+
+// Relations отдает id друзей пользователя.
+type Relations interface {
+	FriendIDs(ctx context.Context, userID string) ([]string, error)
+}
+
+// Chat отдает переписки пользователя, последние - первыми.
+type Chat interface {
+	Conversations(ctx context.Context, userID string) ([]Conversation, error)
+}
+
+// Accounts отдает профили пользователей по id; неизвестные id пропускаются.
+type Accounts interface {
+	Profiles(ctx context.Context, ids []string) (map[string]Profile, error)
+}
+
+// RelationsClient получает друзей через RelationsService.ListFriends
+// от имени пользователя.
+type RelationsClient struct {
+	Client rpb.RelationsServiceClient
+}
+
+func (r RelationsClient) FriendIDs(ctx context.Context, userID string) ([]string, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-user-id", userID)
+	resp, err := r.Client.ListFriends(ctx, &rpb.ListFriendsRequest{
+		Filter:   rpb.FriendFilter_FRIEND_FILTER_ACCEPTED,
+		PageSize: maxFriends,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(resp.GetFriends()))
+	for _, f := range resp.GetFriends() {
+		ids = append(ids, strconv.FormatUint(f.GetUserId(), 10))
+	}
+	return ids, nil
+}
+
+// ChatClient получает переписки через ChatService.ListConversations
+// от имени пользователя.
+type ChatClient struct {
+	Client cpb.ChatServiceClient
+}
+
+func (c ChatClient) Conversations(ctx context.Context, userID string) ([]Conversation, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-user-id", userID)
+	resp, err := c.Client.ListConversations(ctx, &cpb.ListConversationsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Conversation, 0, len(resp.GetConversations()))
+	for _, conv := range resp.GetConversations() {
+		c := Conversation{ReadOnly: conv.GetReadOnly()}
+		if conv.GetId() != 0 {
+			c.ID = strconv.FormatUint(conv.GetId(), 10)
+			c.Title = conv.GetTitle()
+		} else {
+			c.PeerID = strconv.FormatUint(conv.GetPeerId(), 10)
+		}
+		// в групповую переписку могли еще не писать
+		if m := conv.GetLastMessage(); m != nil {
+			c.LastMessage = &Message{
+				SenderID: strconv.FormatUint(m.GetSenderId(), 10),
+				Text:     m.GetText(),
+				SentAt:   m.GetSentAt().AsTime(),
+			}
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// AccountsClient получает профили через AccountsService.GetUsers.
+type AccountsClient struct {
+	Client pb.AccountsServiceClient
+}
+
+func (a AccountsClient) Profiles(ctx context.Context, ids []string) (map[string]Profile, error) {
+	req := &pb.GetUsersRequest{Ids: make([]uint64, 0, len(ids))}
+	for _, id := range ids {
+		n, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user id %q", id)
+		}
+		req.Ids = append(req.Ids, n)
+	}
+
+	resp, err := a.Client.GetUsers(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make(map[string]Profile, len(resp.GetUsers()))
+	for _, u := range resp.GetUsers() {
+		id := strconv.FormatUint(u.GetId(), 10)
+		profiles[id] = Profile{ID: id, Username: u.GetUsername()}
+	}
+	return profiles, nil
+}
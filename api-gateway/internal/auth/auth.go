@@ -0,0 +1,192 @@
+// Package auth проверяет access токены в api-gateway локально, без запроса
+// в accounts на каждый вызов: ключи подписи и список отозванных сессий
+// периодически забираются из accounts и хранятся в памяти.
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	pb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+	"github.com/zura-t/go_messenger/accounts/pkg/token"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var ErrRevoked = errors.New("session has been revoked")
+
+// keysRefreshInterval ограничивает, как часто токен с незнакомым kid
+// может вызвать внеочередную загрузку ключей.
+const keysRefreshInterval = 10 * time.Second
+
+// Authenticator проверяет подпись, срок действия и отзыв access токенов.
+type Authenticator struct {
+	client   pb.AccountsServiceClient
+	interval time.Duration
+
+	mx         sync.RWMutex
+	keys       map[string]ed25519.PublicKey
+	keysLoaded time.Time
+	revoked    map[string]time.Time // id сессии -> когда истекут ее токены
+	watermark  time.Time            // время последнего известного отзыва
+	synced     time.Time
+}
+
+// New создает Authenticator. interval - период синхронизации с accounts,
+// он же задает, через сколько отзыв сессии начнет действовать в gateway.
+func New(client pb.AccountsServiceClient, interval time.Duration) *Authenticator {
+	return &Authenticator{
+		client:   client,
+		interval: interval,
+		keys:     make(map[string]ed25519.PublicKey),
+		revoked:  make(map[string]time.Time),
+	}
+}
+
+// Run синхронизируется с accounts каждые interval, пока не отменен ctx.
+func (a *Authenticator) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.Sync(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("auth: sync with accounts failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync загружает ключи подписи и сессии, отозванные после предыдущей синхронизации.
+func (a *Authenticator) Sync(ctx context.Context) error {
+	if err := a.loadKeys(ctx); err != nil {
+		return err
+	}
+
+	a.mx.RLock()
+	since := a.watermark
+	a.mx.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, a.interval)
+	defer cancel()
+
+	resp, err := a.client.ListRevokedSessions(ctx, &pb.ListRevokedSessionsRequest{Since: timestamp(since)})
+	if err != nil {
+		return fmt.Errorf("list revoked sessions: %w", err)
+	}
+
+	now := time.Now()
+
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	for _, s := range resp.GetSessions() {
+		a.revoked[s.GetId()] = s.GetExpiresAt().AsTime()
+		if revokedAt := s.GetRevokedAt().AsTime(); revokedAt.After(a.watermark) {
+			a.watermark = revokedAt
+		}
+	}
+	for id, expiresAt := range a.revoked {
+		if now.After(expiresAt) {
+			delete(a.revoked, id)
+		}
+	}
+	a.synced = now
+
+	return nil
+}
+
+func (a *Authenticator) loadKeys(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, a.interval)
+	defer cancel()
+
+	resp, err := a.client.GetSigningKeys(ctx, &pb.GetSigningKeysRequest{})
+	if err != nil {
+		return fmt.Errorf("get signing keys: %w", err)
+	}
+
+	keys := make(map[string]ed25519.PublicKey, len(resp.GetKeys()))
+	for _, k := range resp.GetKeys() {
+		if k.GetAlgorithm() != token.Algorithm || len(k.GetPublicKey()) != ed25519.PublicKeySize {
+			continue
+		}
+		keys[k.GetId()] = ed25519.PublicKey(k.GetPublicKey())
+	}
+
+	a.mx.Lock()
+	a.keys = keys
+	a.keysLoaded = time.Now()
+	a.mx.Unlock()
+
+	return nil
+}
+
+// Verify проверяет access токен. Если токен подписан незнакомым ключом,
+// например после смены ключа в accounts, ключи загружаются заново.
+func (a *Authenticator) Verify(ctx context.Context, raw string) (*token.Claims, error) {
+	claims, err := token.Verify(raw, a.key)
+	if errors.Is(err, token.ErrUnknownKey) && a.keysStale() {
+		if err := a.loadKeys(ctx); err != nil {
+			return nil, err
+		}
+		claims, err = token.Verify(raw, a.key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a.mx.RLock()
+	_, revoked := a.revoked[claims.SessionID]
+	a.mx.RUnlock()
+	if revoked {
+		return nil, ErrRevoked
+	}
+
+	return claims, nil
+}
+
+// Check - проверка готовности: ключи загружены, а список отзывов
+// обновлялся не дольше трех периодов синхронизации назад.
+func (a *Authenticator) Check(ctx context.Context) error {
+	a.mx.RLock()
+	defer a.mx.RUnlock()
+
+	if len(a.keys) == 0 {
+		return errors.New("signing keys are not loaded")
+	}
+	if time.Since(a.synced) > 3*a.interval {
+		return fmt.Errorf("revoked sessions were last synced at %s", a.synced.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (a *Authenticator) key(kid string) (ed25519.PublicKey, bool) {
+	a.mx.RLock()
+	defer a.mx.RUnlock()
+
+	key, ok := a.keys[kid]
+	return key, ok
+}
+
+func (a *Authenticator) keysStale() bool {
+	a.mx.RLock()
+	defer a.mx.RUnlock()
+
+	return time.Since(a.keysLoaded) > keysRefreshInterval
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
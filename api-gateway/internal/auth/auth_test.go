@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+	"github.com/zura-t/go_messenger/accounts/pkg/token"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeAccounts отдает ключи и отзывы, как accounts, и запоминает вызовы.
+type fakeAccounts struct {
+	pb.AccountsServiceClient
+
+	mx       sync.Mutex
+	keys     []ed25519.PrivateKey
+	keysErr  error
+	keyCalls int
+	revoked  []*pb.RevokedSession
+	since    []time.Time // since каждого вызова ListRevokedSessions
+}
+
+func (f *fakeAccounts) GetSigningKeys(context.Context, *pb.GetSigningKeysRequest, ...grpc.CallOption) (*pb.GetSigningKeysResponse, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	f.keyCalls++
+	if f.keysErr != nil {
+		return nil, f.keysErr
+	}
+	resp := &pb.GetSigningKeysResponse{}
+	for _, k := range f.keys {
+		id, public := token.NewMaker(k, time.Minute).PublicKey()
+		resp.Keys = append(resp.Keys, &pb.SigningKey{Id: id, Algorithm: token.Algorithm, PublicKey: public})
+	}
+	return resp, nil
+}
+
+// ListRevokedSessions отдает сессии, отозванные позже since, как accounts.
+func (f *fakeAccounts) ListRevokedSessions(_ context.Context, req *pb.ListRevokedSessionsRequest, _ ...grpc.CallOption) (*pb.ListRevokedSessionsResponse, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	var since time.Time
+	if req.GetSince() != nil {
+		since = req.GetSince().AsTime()
+	}
+	f.since = append(f.since, since)
+
+	resp := &pb.ListRevokedSessionsResponse{}
+	for _, s := range f.revoked {
+		if s.GetRevokedAt().AsTime().After(since) {
+			resp.Sessions = append(resp.Sessions, s)
+		}
+	}
+	return resp, nil
+}
+
+func (f *fakeAccounts) revoke(id string, at time.Time) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	f.revoked = append(f.revoked, &pb.RevokedSession{
+		Id:        id,
+		RevokedAt: timestamppb.New(at),
+		ExpiresAt: timestamppb.New(at.Add(time.Hour)),
+	})
+}
+
+func newKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	key, err := token.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newToken(t *testing.T, key ed25519.PrivateKey, ttl time.Duration, userID uint64, session string) string {
+	t.Helper()
+	raw, err := token.NewMaker(key, ttl).CreateAccessToken(userID, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// newAuthenticator возвращает Authenticator, уже синхронизированный с accounts.
+func newAuthenticator(t *testing.T, accounts *fakeAccounts) *Authenticator {
+	t.Helper()
+	a := New(accounts, time.Second)
+	if err := a.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestVerify(t *testing.T) {
+	key, other := newKey(t), newKey(t)
+	accounts := &fakeAccounts{keys: []ed25519.PrivateKey{key}}
+	accounts.revoke("revoked", time.Now())
+	a := newAuthenticator(t, accounts)
+
+	valid := newToken(t, key, time.Minute, 7, "session")
+	forged := newToken(t, other, time.Minute, 7, "session")
+
+	tests := []struct {
+		name    string
+		raw     string
+		subject string
+		err     error
+	}{
+		{"valid", valid, "7", nil},
+		{"bad signature", tamper(valid), "", token.ErrInvalidToken},
+		{"malformed", "not-a-token", "", token.ErrInvalidToken},
+		{"unknown kid", forged, "", token.ErrUnknownKey},
+		{"expired", newToken(t, key, -time.Minute, 7, "session"), "", token.ErrExpiredToken},
+		{"revoked session", newToken(t, key, time.Minute, 7, "revoked"), "", ErrRevoked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := a.Verify(context.Background(), tt.raw)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err == nil && claims.Subject != tt.subject {
+				t.Errorf("subject = %q, want %q", claims.Subject, tt.subject)
+			}
+		})
+	}
+}
+
+// tamper меняет последний символ подписи, не трогая заголовок и claims.
+func tamper(raw string) string {
+	last := raw[len(raw)-2]
+	replacement := byte('A')
+	if last == 'A' {
+		replacement = 'B'
+	}
+	return raw[:len(raw)-2] + string(replacement) + raw[len(raw)-1:]
+}
+
+func TestVerifyReloadsKeysThrottled(t *testing.T) {
+	key, rotated := newKey(t), newKey(t)
+	accounts := &fakeAccounts{keys: []ed25519.PrivateKey{key}}
+	a := newAuthenticator(t, accounts)
+	raw := newToken(t, rotated, time.Minute, 7, "session")
+
+	// accounts сменил ключ, но ключи загружены только что:
+	// незнакомый kid не вызывает повторную загрузку
+	accounts.keys = append(accounts.keys, rotated)
+	for range 3 {
+		if _, err := a.Verify(context.Background(), raw); !errors.Is(err, token.ErrUnknownKey) {
+			t.Fatalf("err = %v, want %v", err, token.ErrUnknownKey)
+		}
+	}
+	if accounts.keyCalls != 1 {
+		t.Fatalf("GetSigningKeys calls = %d, want 1", accounts.keyCalls)
+	}
+
+	// по прошествии keysRefreshInterval ключи загружаются заново
+	a.mx.Lock()
+	a.keysLoaded = time.Now().Add(-keysRefreshInterval - time.Second)
+	a.mx.Unlock()
+	claims, err := a.Verify(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "7" {
+		t.Errorf("subject = %q, want 7", claims.Subject)
+	}
+
+	// и снова не чаще keysRefreshInterval
+	unknown := newToken(t, newKey(t), time.Minute, 7, "session")
+	for range 3 {
+		a.Verify(context.Background(), unknown)
+	}
+	if accounts.keyCalls != 2 {
+		t.Errorf("GetSigningKeys calls = %d, want 2", accounts.keyCalls)
+	}
+
+	// недоступность accounts при загрузке - не ошибка токена
+	accounts.keysErr = errors.New("unavailable")
+	a.mx.Lock()
+	a.keysLoaded = time.Time{}
+	a.mx.Unlock()
+	if _, err := a.Verify(context.Background(), unknown); err == nil || errors.Is(err, token.ErrUnknownKey) {
+		t.Errorf("err = %v, want the accounts error", err)
+	}
+}
+
+func TestSyncRevokedSinceWatermark(t *testing.T) {
+	key := newKey(t)
+	accounts := &fakeAccounts{keys: []ed25519.PrivateKey{key}}
+	a := newAuthenticator(t, accounts)
+
+	first := newToken(t, key, time.Minute, 7, "first")
+	second := newToken(t, key, time.Minute, 8, "second")
+	if _, err := a.Verify(context.Background(), first); err != nil {
+		t.Fatal(err)
+	}
+
+	// отзыв начинает действовать после следующей синхронизации
+	revokedAt := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	accounts.revoke("first", revokedAt)
+	if _, err := a.Verify(context.Background(), first); err != nil {
+		t.Fatalf("revoked before sync: %v", err)
+	}
+	if err := a.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Verify(context.Background(), first); !errors.Is(err, ErrRevoked) {
+		t.Errorf("err = %v, want %v", err, ErrRevoked)
+	}
+
+	accounts.revoke("second", revokedAt.Add(time.Second))
+	if err := a.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, raw := range []string{first, second} {
+		if _, err := a.Verify(context.Background(), raw); !errors.Is(err, ErrRevoked) {
+			t.Errorf("err = %v, want %v", err, ErrRevoked)
+		}
+	}
+
+	// отзывы не теряются, когда новых нет
+	if err := a.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Verify(context.Background(), second); !errors.Is(err, ErrRevoked) {
+		t.Errorf("err = %v, want %v", err, ErrRevoked)
+	}
+
+	// каждая синхронизация спрашивает только отзывы после последнего известного
+	want := []time.Time{{}, {}, revokedAt, revokedAt.Add(time.Second)}
+	if len(accounts.since) != len(want) {
+		t.Fatalf("ListRevokedSessions calls = %d, want %d", len(accounts.since), len(want))
+	}
+	for i := range want {
+		if !accounts.since[i].Equal(want[i]) {
+			t.Errorf("call %d since = %v, want %v", i, accounts.since[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/zura-t/go_messenger/accounts/pkg/token"
+)
+
+// UserIDHeader - заголовок, в котором HTTP бэкенды получают публичный id
+// аутентифицированного пользователя. В gRPC метаданных он называется x-user-id.
+const UserIDHeader = "X-User-Id"
+
+// grpc-gateway превращает заголовки с этим префиксом в gRPC метаданные,
+// поэтому клиент мог бы подделать x-user-id и через него.
+const grpcMetadataPrefix = "Grpc-Metadata-"
+
+type Access int
+
+const (
+	// Public - маршрут доступен без access токена.
+	Public Access = iota + 1
+	// Protected - маршрут требует действующий access токен.
+	Protected
+)
+
+// Policy задает доступ к маршрутам по ключу "МЕТОД путь", где путь -
+// шаблон маршрута Echo: "DELETE /accounts/sessions/:id".
+type Policy map[string]Access
+
+// Validate проверяет, что для каждого зарегистрированного маршрута
+// доступ объявлен явно. Вызывается после регистрации всех маршрутов,
+// чтобы новый маршрут нельзя было случайно оставить открытым.
+func (p Policy) Validate(routes []*echo.Route) error {
+	var missing []string
+	for _, r := range routes {
+		if _, ok := p[r.Method+" "+r.Path]; !ok {
+			missing = append(missing, r.Method+" "+r.Path)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("access is not declared for routes: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Middleware пропускает запросы к защищенным маршрутам только с действующим
+// access токеном и передает id пользователя бэкендам в заголовке UserIDHeader.
+// Запросы, не совпавшие ни с одним маршрутом, пропускаются к Echo как есть,
+// чтобы тот ответил 404 или 405.
+func (a *Authenticator) Middleware(policy Policy) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			r.Header.Del(UserIDHeader)
+			r.Header.Del(grpcMetadataPrefix + UserIDHeader)
+
+			if policy[r.Method+" "+c.Path()] != Protected {
+				return next(c)
+			}
+
+			raw, ok := strings.CutPrefix(r.Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || raw == "" {
+				return unauthorized(c, "access token is required")
+			}
+
+			claims, err := a.Verify(r.Context(), raw)
+			switch {
+			case errors.Is(err, token.ErrExpiredToken), errors.Is(err, ErrRevoked),
+				errors.Is(err, token.ErrInvalidToken), errors.Is(err, token.ErrUnknownKey):
+				return unauthorized(c, err.Error())
+			case err != nil:
+				c.Logger().Errorf("auth: verify token: %v", err)
+				return echo.NewHTTPError(http.StatusServiceUnavailable, "authentication is temporarily unavailable")
+			}
+
+			r.Header.Set(UserIDHeader, claims.Subject)
+			c.SetRequest(r.WithContext(WithUserID(r.Context(), claims.Subject)))
+
+			return next(c)
+		}
+	}
+}
+
+func unauthorized(c echo.Context, message string) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+	return echo.NewHTTPError(http.StatusUnauthorized, message)
+}
+
+type userIDKey struct{}
+
+// WithUserID возвращает ctx пользователя id, как после проверки его
+// access токена: так обработчики вызываются в обход Middleware, например в тестах.
+func WithUserID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, id)
+}
+
+// UserID возвращает публичный id пользователя, прошедшего аутентификацию.
+func UserID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(userIDKey{}).(string)
+	return id, ok
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestMiddleware(t *testing.T) {
+	key := newKey(t)
+	accounts := &fakeAccounts{keys: []ed25519.PrivateKey{key}}
+	accounts.revoke("revoked", time.Now())
+	a := newAuthenticator(t, accounts)
+
+	policy := Policy{
+		"GET /public": Public,
+		"GET /me":     Protected,
+	}
+	e := echo.New()
+	mw := a.Middleware(policy)
+	// обработчик отдает то, что middleware передал бэкенду
+	echoUser := func(c echo.Context) error {
+		id, _ := UserID(c.Request().Context())
+		h := c.Request().Header
+		return c.String(http.StatusOK, strings.Join([]string{id, h.Get(UserIDHeader), h.Get(grpcMetadataPrefix + UserIDHeader)}, "|"))
+	}
+	e.GET("/public", echoUser, mw)
+	e.GET("/me", echoUser, mw)
+
+	valid := newToken(t, key, time.Minute, 7, "session")
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		status        int
+		body          string
+	}{
+		{"public without token", "/public", "", http.StatusOK, "||"},
+		{"public ignores token", "/public", "Bearer " + valid, http.StatusOK, "||"},
+		{"no token", "/me", "", http.StatusUnauthorized, ""},
+		{"not bearer", "/me", "Basic " + valid, http.StatusUnauthorized, ""},
+		{"valid", "/me", "Bearer " + valid, http.StatusOK, "7|7|"},
+		{"bad signature", "/me", "Bearer " + tamper(valid), http.StatusUnauthorized, ""},
+		{"unknown kid", "/me", "Bearer " + newToken(t, newKey(t), time.Minute, 7, "session"), http.StatusUnauthorized, ""},
+		{"expired", "/me", "Bearer " + newToken(t, key, -time.Minute, 7, "session"), http.StatusUnauthorized, ""},
+		{"revoked", "/me", "Bearer " + newToken(t, key, time.Minute, 7, "revoked"), http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			// клиент пытается представиться другим пользователем
+			req.Header.Set(UserIDHeader, "1")
+			req.Header.Set(grpcMetadataPrefix+UserIDHeader, "1")
+			if tt.authorization != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status == http.StatusUnauthorized {
+				if got := rec.Header().Get(echo.HeaderWWWAuthenticate); got != "Bearer" {
+					t.Errorf("WWW-Authenticate = %q, want Bearer", got)
+				}
+				return
+			}
+			if rec.Body.String() != tt.body {
+				t.Errorf("user id (context|header|grpc header) = %q, want %q", rec.Body, tt.body)
+			}
+		})
+	}
+}
+
+func TestMiddlewareAccountsUnavailable(t *testing.T) {
+	accounts := &fakeAccounts{keys: []ed25519.PrivateKey{newKey(t)}}
+	a := newAuthenticator(t, accounts)
+	accounts.keysErr = errors.New("unavailable")
+	a.keysLoaded = time.Time{}
+
+	e := echo.New()
+	e.GET("/me", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, a.Middleware(Policy{"GET /me": Protected}))
+
+	// ключ токена неизвестен, а загрузить новые ключи нельзя:
+	// это сбой gateway, а не неверный токен
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+newToken(t, newKey(t), time.Minute, 7, "session"))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	routes := []*echo.Route{
+		{Method: http.MethodGet, Path: "/me"},
+		{Method: http.MethodDelete, Path: "/accounts/sessions/:id"},
+		{Method: http.MethodPost, Path: "/login"},
+	}
+	tests := []struct {
+		name    string
+		policy  Policy
+		missing string
+	}{
+		{"all declared", Policy{
+			"GET /me":                       Protected,
+			"DELETE /accounts/sessions/:id": Protected,
+			"POST /login":                   Public,
+		}, ""},
+		{"route missing", Policy{
+			"GET /me":     Protected,
+			"POST /login": Public,
+		}, "DELETE /accounts/sessions/:id"},
+		{"other method declared", Policy{
+			"GET /me":                    Protected,
+			"GET /accounts/sessions/:id": Protected,
+			"GET /login":                 Public,
+		}, "DELETE /accounts/sessions/:id, POST /login"},
+		{"empty", Policy{}, "DELETE /accounts/sessions/:id, GET /me, POST /login"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(routes)
+			if tt.missing == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasSuffix(err.Error(), ": "+tt.missing) {
+				t.Errorf("Validate() = %v, want routes %q", err, tt.missing)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package browser
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	AccessCookie  = "access_token"
+	RefreshCookie = "refresh_token"
+	// CSRFCookie читается скриптом страницы и возвращается в CSRFHeader:
+	// чужой сайт не может прочитать cookie и подставить заголовок.
+	CSRFCookie = "csrf_token"
+	CSRFHeader = echo.HeaderXCSRFToken
+	// ModeHeader со значением "cookie" на входе просит выдать токены в cookie.
+	ModeHeader = "X-Auth-Mode"
+)
+
+// Sessions - режим, в котором браузер хранит токены в HttpOnly cookie,
+// а не передает их в Authorization. Запросы, меняющие данные, в этом
+// режиме принимаются только с CSRF токеном (double submit).
+type Sessions struct {
+	// Issue - маршруты, в ответе которых accounts выдает пару токенов.
+	Issue []string
+	// Refresh - маршрут обновления токенов, refresh_token передается в теле.
+	Refresh string
+	// Logout - маршрут выхода, после него cookie удаляются.
+	Logout     string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// Middleware должен стоять перед middleware аутентификации:
+// токен из cookie подставляется в заголовок Authorization.
+func (s Sessions) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			route := r.Method + " " + c.Path()
+			fromCookie := false
+
+			// на входе и регистрации старые cookie не нужны
+			if r.Header.Get(echo.HeaderAuthorization) == "" && !slices.Contains(s.Issue, route) {
+				if cookie, err := r.Cookie(AccessCookie); err == nil && cookie.Value != "" {
+					if err := checkCSRF(r); err != nil {
+						return err
+					}
+					r.Header.Set(echo.HeaderAuthorization, "Bearer "+cookie.Value)
+					fromCookie = true
+				}
+			}
+
+			if route == s.Refresh {
+				if cookie, err := r.Cookie(RefreshCookie); err == nil && cookie.Value != "" {
+					if err := checkCSRF(r); err != nil {
+						return err
+					}
+					if err := setRefreshToken(r, cookie.Value); err != nil {
+						return err
+					}
+					fromCookie = true
+				}
+			}
+
+			if route == s.Logout && fromCookie {
+				// cookie удаляются, только если выход удался
+				c.Response().Before(func() {
+					if c.Response().Status == http.StatusOK {
+						s.clearCookies(c)
+					}
+				})
+				return next(c)
+			}
+
+			issues := slices.Contains(s.Issue, route) || route == s.Refresh
+			if !issues || !fromCookie && r.Header.Get(ModeHeader) != "cookie" {
+				return next(c)
+			}
+
+			rec := &capture{ResponseWriter: c.Response().Writer, status: http.StatusOK}
+			c.Response().Writer = rec
+			err := next(c)
+			c.Response().Writer = rec.ResponseWriter
+			c.Response().Committed = false
+
+			body := rec.body.Bytes()
+			if err == nil && rec.status == http.StatusOK {
+				body, err = s.moveTokens(c, body)
+				if err != nil {
+					return err
+				}
+			}
+			if len(body) == 0 && err != nil {
+				return err
+			}
+
+			c.Response().Header().Del(echo.HeaderContentLength)
+			c.Response().WriteHeader(rec.status)
+			c.Response().Write(body)
+			return err
+		}
+	}
+}
+
+// moveTokens убирает токены из ответа и выставляет их в cookie
+// вместе с новым CSRF токеном.
+func (s Sessions) moveTokens(c echo.Context, body []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body, nil
+	}
+
+	var access, refresh string
+	json.Unmarshal(fields["access_token"], &access)
+	json.Unmarshal(fields["refresh_token"], &refresh)
+	if access == "" || refresh == "" {
+		return body, nil
+	}
+	delete(fields, "access_token")
+	delete(fields, "refresh_token")
+
+	csrf := make([]byte, 32)
+	if _, err := rand.Read(csrf); err != nil {
+		return nil, err
+	}
+
+	c.SetCookie(s.cookie(AccessCookie, access, "/", s.AccessTTL, true))
+	c.SetCookie(s.cookie(RefreshCookie, refresh, s.refreshPath(), s.RefreshTTL, true))
+	c.SetCookie(s.cookie(CSRFCookie, base64.RawURLEncoding.EncodeToString(csrf), "/", s.RefreshTTL, false))
+
+	return json.Marshal(fields)
+}
+
+func (s Sessions) clearCookies(c echo.Context) {
+	c.SetCookie(s.cookie(AccessCookie, "", "/", -1, true))
+	c.SetCookie(s.cookie(RefreshCookie, "", s.refreshPath(), -1, true))
+	c.SetCookie(s.cookie(CSRFCookie, "", "/", -1, false))
+}
+
+func (s Sessions) cookie(name, value, path string, ttl time.Duration, httpOnly bool) *http.Cookie {
+	maxAge := int(ttl.Seconds())
+	if ttl < 0 {
+		maxAge = -1
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: httpOnly,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+// refreshPath ограничивает refresh cookie маршрутом обновления токенов,
+// чтобы он не уходил с остальными запросами.
+func (s Sessions) refreshPath() string {
+	_, path, _ := strings.Cut(s.Refresh, " ")
+	return path
+}
+
+// checkCSRF пропускает безопасные методы, а для остальных требует,
+// чтобы заголовок CSRFHeader совпадал с cookie CSRFCookie.
+func checkCSRF(r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	cookie, err := r.Cookie(CSRFCookie)
+	header := r.Header.Get(CSRFHeader)
+	if err != nil || cookie.Value == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+		return echo.NewHTTPError(http.StatusForbidden, "CSRF token is missing or invalid")
+	}
+	return nil
+}
+
+// setRefreshToken подставляет refresh токен из cookie в тело запроса,
+// если клиент не передал его сам.
+func setRefreshToken(r *http.Request, token string) error {
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if len(bytes.TrimSpace(raw)) > 0 {
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "request body must be a JSON object")
+		}
+	}
+	if _, ok := fields["refresh_token"]; !ok {
+		fields["refresh_token"], _ = json.Marshal(token)
+	}
+
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	return nil
+}
+
+// capture придерживает ответ, чтобы убрать из него токены.
+type capture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *capture) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *capture) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
@@ -0,0 +1,244 @@
+package browser
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var sessions = Sessions{
+	Issue:      []string{"POST /v1/login"},
+	Refresh:    "POST /v1/refresh_token",
+	Logout:     "POST /v1/logout",
+	AccessTTL:  15 * time.Minute,
+	RefreshTTL: 24 * time.Hour,
+}
+
+// newSessionsServer - gateway с маршрутами сессий. Обработчики отвечают
+// заголовком Authorization и телом запроса, которые дошли до бэкенда.
+func newSessionsServer() *echo.Echo {
+	e := echo.New()
+	e.Use(sessions.Middleware())
+
+	backend := func(c echo.Context) error {
+		body, _ := io.ReadAll(c.Request().Body)
+		return c.JSON(http.StatusOK, map[string]string{
+			"authorization": c.Request().Header.Get(echo.HeaderAuthorization),
+			"body":          string(body),
+		})
+	}
+	issue := func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{
+			"access_token":  "new-access",
+			"refresh_token": "new-refresh",
+			"user_id":       "7",
+		})
+	}
+	e.GET("/v1/me", backend)
+	e.POST("/v1/chat/message", backend)
+	e.POST("/v1/logout", backend)
+	e.POST("/v1/login", issue)
+	e.POST("/v1/refresh_token", issue)
+	return e
+}
+
+func cookieRequest(method, path, body string, cookies map[string]string, headers map[string]string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	for name, value := range cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	return req
+}
+
+func TestSessionsCSRF(t *testing.T) {
+	e := newSessionsServer()
+	session := map[string]string{AccessCookie: "access", CSRFCookie: "csrf"}
+
+	tests := []struct {
+		name          string
+		method, path  string
+		cookies       map[string]string
+		headers       map[string]string
+		status        int
+		authorization string
+	}{
+		{"unsafe without CSRF header", http.MethodPost, "/v1/chat/message", session, nil,
+			http.StatusForbidden, ""},
+		{"CSRF header does not match cookie", http.MethodPost, "/v1/chat/message", session,
+			map[string]string{CSRFHeader: "other"}, http.StatusForbidden, ""},
+		{"CSRF header without cookie", http.MethodPost, "/v1/chat/message", map[string]string{AccessCookie: "access"},
+			map[string]string{CSRFHeader: "csrf"}, http.StatusForbidden, ""},
+		{"matching CSRF header", http.MethodPost, "/v1/chat/message", session,
+			map[string]string{CSRFHeader: "csrf"}, http.StatusOK, "Bearer access"},
+		{"safe method", http.MethodGet, "/v1/me", session, nil,
+			http.StatusOK, "Bearer access"},
+		// токен в заголовке не может подставить чужой сайт, CSRF не нужен
+		{"authorization header", http.MethodPost, "/v1/chat/message", session,
+			map[string]string{echo.HeaderAuthorization: "Bearer header"}, http.StatusOK, "Bearer header"},
+		{"no cookies", http.MethodPost, "/v1/chat/message", nil, nil,
+			http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, cookieRequest(tt.method, tt.path, "{}", tt.cookies, tt.headers))
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var got map[string]string
+			json.Unmarshal(rec.Body.Bytes(), &got)
+			if got["authorization"] != tt.authorization {
+				t.Errorf("authorization = %q, want %q", got["authorization"], tt.authorization)
+			}
+		})
+	}
+}
+
+func TestSessionsIssueCookies(t *testing.T) {
+	e := newSessionsServer()
+
+	tests := []struct {
+		name    string
+		path    string
+		cookies map[string]string
+		headers map[string]string
+	}{
+		{"login in cookie mode", "/v1/login", nil, map[string]string{ModeHeader: "cookie"}},
+		// старые cookie на входе не проверяются и не мешают
+		{"login with stale cookies", "/v1/login", map[string]string{AccessCookie: "old"}, map[string]string{ModeHeader: "cookie"}},
+		{"refresh by cookie", "/v1/refresh_token",
+			map[string]string{RefreshCookie: "refresh", CSRFCookie: "csrf"},
+			map[string]string{CSRFHeader: "csrf"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, cookieRequest(http.MethodPost, tt.path, "{}", tt.cookies, tt.headers))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+			}
+
+			var body map[string]string
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if _, ok := body["access_token"]; ok {
+				t.Error("access_token is left in the response")
+			}
+			if _, ok := body["refresh_token"]; ok {
+				t.Error("refresh_token is left in the response")
+			}
+			if body["user_id"] != "7" {
+				t.Errorf("response body = %s, other fields must be kept", rec.Body)
+			}
+
+			cookies := responseCookies(rec)
+			checkCookie(t, cookies[AccessCookie], "new-access", "/", true, int(sessions.AccessTTL.Seconds()))
+			// refresh cookie уходит только на маршрут обновления
+			checkCookie(t, cookies[RefreshCookie], "new-refresh", "/v1/refresh_token", true, int(sessions.RefreshTTL.Seconds()))
+			// CSRF cookie читает скрипт страницы
+			checkCookie(t, cookies[CSRFCookie], "", "/", false, int(sessions.RefreshTTL.Seconds()))
+			if cookies[CSRFCookie] != nil && (cookies[CSRFCookie].Value == "" || cookies[CSRFCookie].Value == "csrf") {
+				t.Errorf("csrf cookie = %q, want a new token", cookies[CSRFCookie].Value)
+			}
+		})
+	}
+}
+
+func TestSessionsRefreshCookie(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{"empty body", "", http.StatusOK, `{"refresh_token":"refresh"}`},
+		{"token from cookie", "{}", http.StatusOK, `{"refresh_token":"refresh"}`},
+		{"client token kept", `{"refresh_token":"client"}`, http.StatusOK, `{"refresh_token":"client"}`},
+		{"not an object", "[]", http.StatusBadRequest, ""},
+	}
+	refresh := Sessions{Refresh: "POST /v1/refresh_token"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			var got string
+			e.POST("/v1/refresh_token", func(c echo.Context) error {
+				body, _ := io.ReadAll(c.Request().Body)
+				got = string(body)
+				return c.NoContent(http.StatusOK)
+			}, refresh.Middleware())
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, cookieRequest(http.MethodPost, "/v1/refresh_token", tt.body,
+				map[string]string{RefreshCookie: "refresh", CSRFCookie: "csrf"},
+				map[string]string{CSRFHeader: "csrf"}))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("backend got body %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// без CSRF заголовка refresh cookie не принимается
+	rec := httptest.NewRecorder()
+	newSessionsServer().ServeHTTP(rec, cookieRequest(http.MethodPost, "/v1/refresh_token", "{}",
+		map[string]string{RefreshCookie: "refresh", CSRFCookie: "csrf"}, nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("refresh without CSRF header: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestSessionsLogoutClearsCookies(t *testing.T) {
+	e := newSessionsServer()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, cookieRequest(http.MethodPost, "/v1/logout", "{}",
+		map[string]string{AccessCookie: "access", CSRFCookie: "csrf"},
+		map[string]string{CSRFHeader: "csrf"}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+
+	cookies := responseCookies(rec)
+	checkCookie(t, cookies[AccessCookie], "", "/", true, -1)
+	checkCookie(t, cookies[RefreshCookie], "", "/v1/refresh_token", true, -1)
+	checkCookie(t, cookies[CSRFCookie], "", "/", false, -1)
+}
+
+func responseCookies(rec *httptest.ResponseRecorder) map[string]*http.Cookie {
+	cookies := make(map[string]*http.Cookie)
+	for _, c := range rec.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	return cookies
+}
+
+// checkCookie сверяет атрибуты cookie; пустой value не проверяется.
+func checkCookie(t *testing.T, c *http.Cookie, value, path string, httpOnly bool, maxAge int) {
+	t.Helper()
+	if c == nil {
+		t.Errorf("cookie with path %s is not set", path)
+		return
+	}
+	if value != "" && c.Value != value {
+		t.Errorf("%s = %q, want %q", c.Name, c.Value, value)
+	}
+	if c.Path != path || c.HttpOnly != httpOnly || c.MaxAge != maxAge || !c.Secure || c.SameSite != http.SameSiteStrictMode {
+		t.Errorf("%s: path %q, HttpOnly %v, Max-Age %d, Secure %v, SameSite %v; want path %q, HttpOnly %v, Max-Age %d, Secure, SameSite=Strict",
+			c.Name, c.Path, c.HttpOnly, c.MaxAge, c.Secure, c.SameSite, path, httpOnly, maxAge)
+	}
+}
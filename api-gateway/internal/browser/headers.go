@@ -0,0 +1,57 @@
+// Package browser - middleware gateway для клиентов в браузере: CORS,
+// заголовки безопасности и хранение токенов в cookie с защитой от CSRF.
+package browser
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/zura-t/go_messenger/platform/config"
+)
+
+// CORS разрешает запросы с доменов из browser.allowed_origins. Без них
+// запросы из браузера с других доменов не проходят. Заголовки, которые
+// gateway читает и отдает сам, перечислены явно.
+func CORS(cfg config.Browser) echo.MiddlewareFunc {
+	if cfg.AllowedOrigins == "" {
+		return passThrough
+	}
+
+	var origins []string
+	for _, origin := range strings.Split(cfg.AllowedOrigins, ",") {
+		origins = append(origins, strings.TrimSpace(origin))
+	}
+
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:     origins,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           int(cfg.PreflightMaxAge.Seconds()),
+		AllowHeaders: []string{
+			echo.HeaderAuthorization, echo.HeaderContentType, "If-None-Match",
+			CSRFHeader, ModeHeader,
+		},
+		ExposeHeaders: []string{
+			"ETag", echo.HeaderRetryAfter,
+			"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+			"Deprecation", "Sunset", "Link",
+		},
+	})
+}
+
+// SecurityHeaders добавляет к ответам стандартные заголовки безопасности.
+// HSTS отправляется только на запросы по HTTPS, в том числе пришедшие
+// через прокси с X-Forwarded-Proto: https.
+func SecurityHeaders(cfg config.Browser) echo.MiddlewareFunc {
+	return middleware.SecureWithConfig(middleware.SecureConfig{
+		ContentTypeNosniff:    "nosniff",
+		XFrameOptions:         "DENY",
+		HSTSMaxAge:            int(cfg.HSTSMaxAge.Seconds()),
+		ContentSecurityPolicy: cfg.ContentSecurityPolicy,
+		ReferrerPolicy:        "no-referrer",
+	})
+}
+
+func passThrough(next echo.HandlerFunc) echo.HandlerFunc {
+	return next
+}
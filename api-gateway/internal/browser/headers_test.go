@@ -0,0 +1,58 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/zura-t/go_messenger/api-gateway/internal/openapi"
+	"github.com/zura-t/go_messenger/platform/config"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	cfg := config.Browser{
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		HSTSMaxAge:            time.Hour,
+	}
+	e := echo.New()
+	e.Use(SecurityHeaders(cfg))
+	e.GET("/v1/me", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.GET("/docs", openapi.UI("/docs", "/openapi.json"))
+
+	tests := []struct {
+		name  string
+		path  string
+		https bool
+		csp   string
+		hsts  string
+	}{
+		{"api response", "/v1/me", false, cfg.ContentSecurityPolicy, ""},
+		{"https behind proxy", "/v1/me", true, cfg.ContentSecurityPolicy, "max-age=3600; includeSubdomains"},
+		// странице Swagger UI нужны ее скрипты и стили
+		{"docs page", "/docs", false, "script-src 'self' 'unsafe-inline'", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.https {
+				req.Header.Set(echo.HeaderXForwardedProto, "https")
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			h := rec.Header()
+			if got := h.Values(echo.HeaderContentSecurityPolicy); len(got) != 1 || !strings.Contains(got[0], tt.csp) {
+				t.Errorf("Content-Security-Policy = %q, want one value with %q", got, tt.csp)
+			}
+			if got := h.Get(echo.HeaderStrictTransportSecurity); got != tt.hsts {
+				t.Errorf("Strict-Transport-Security = %q, want %q", got, tt.hsts)
+			}
+			if h.Get(echo.HeaderXContentTypeOptions) != "nosniff" || h.Get(echo.HeaderXFrameOptions) != "DENY" || h.Get("Referrer-Policy") != "no-referrer" {
+				t.Errorf("security headers are missing: %v", h)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package cache кеширует ответы GET маршрутов gateway в памяти.
+// Записи помечаются тегами - id пользователей, чьи данные в них есть, -
+// и сбрасываются по тегу, когда эти данные меняются.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// Entry - сохраненный ответ.
+type Entry struct {
+	ContentType string
+	Body        []byte
+	ETag        string
+	Expires     time.Time
+	Tags        []string
+}
+
+// Memory хранит не больше maxEntries записей; когда места нет,
+// новые ответы не кешируются, пока не истекут старые.
+type Memory struct {
+	maxEntries int
+
+	mx      sync.Mutex
+	entries map[string]*Entry
+	tags    map[string]map[string]struct{} // тег -> ключи записей
+}
+
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*Entry),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+// Get возвращает неистекшую запись.
+func (m *Memory) Get(key string) (*Entry, bool) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(e.Expires) {
+		m.deleteLocked(key)
+		return nil, false
+	}
+	return e, true
+}
+
+func (m *Memory) Set(key string, e *Entry) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	if _, ok := m.entries[key]; ok {
+		m.deleteLocked(key)
+	}
+	if len(m.entries) >= m.maxEntries {
+		m.sweepLocked()
+		if len(m.entries) >= m.maxEntries {
+			return
+		}
+	}
+
+	m.entries[key] = e
+	for _, tag := range e.Tags {
+		if m.tags[tag] == nil {
+			m.tags[tag] = make(map[string]struct{})
+		}
+		m.tags[tag][key] = struct{}{}
+	}
+}
+
+// Invalidate удаляет все записи с тегом tag.
+func (m *Memory) Invalidate(tag string) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	for key := range m.tags[tag] {
+		m.deleteLocked(key)
+	}
+}
+
+// InvalidateUser удаляет записи с профилем пользователя id и ответы,
+// полученные им самим.
+func (m *Memory) InvalidateUser(id string) {
+	m.Invalidate(userTag(id))
+}
+
+// Clear удаляет все записи.
+func (m *Memory) Clear() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	clear(m.entries)
+	clear(m.tags)
+}
+
+func (m *Memory) sweepLocked() {
+	now := time.Now()
+	for key, e := range m.entries {
+		if now.After(e.Expires) {
+			m.deleteLocked(key)
+		}
+	}
+}
+
+func (m *Memory) deleteLocked(key string) {
+	e, ok := m.entries[key]
+	if !ok {
+		return
+	}
+	delete(m.entries, key)
+	for _, tag := range e.Tags {
+		delete(m.tags[tag], key)
+		if len(m.tags[tag]) == 0 {
+			delete(m.tags, tag)
+		}
+	}
+}
+
+// ETag вычисляет сильный ETag по телу ответа.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
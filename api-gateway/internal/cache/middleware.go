@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/zura-t/go_messenger/api-gateway/internal/auth"
+)
+
+// Routes задает время жизни ответов кешируемых GET маршрутов
+// по ключу "МЕТОД путь", как auth.Policy.
+type Routes map[string]time.Duration
+
+// Cache кеширует ответы маршрутов Routes и сбрасывает их после мутаций.
+type Cache struct {
+	store     *Memory
+	routes    Routes
+	mutations map[string]bool
+}
+
+// New создает Cache. mutations - маршруты, успешный ответ которых
+// меняет данные вызвавшего пользователя, например обновление профиля.
+func New(store *Memory, routes Routes, mutations ...string) *Cache {
+	c := &Cache{store: store, routes: routes, mutations: make(map[string]bool)}
+	for _, m := range mutations {
+		c.mutations[m] = true
+	}
+	return c
+}
+
+// Middleware должен стоять после middleware аутентификации: ответы
+// разных пользователей кешируются раздельно.
+func (cc *Cache) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route := c.Request().Method + " " + c.Path()
+
+			if cc.mutations[route] {
+				err := next(c)
+				if status := c.Response().Status; err == nil && status >= 200 && status < 300 {
+					if id, ok := auth.UserID(c.Request().Context()); ok {
+						cc.store.Invalidate(userTag(id))
+					}
+				}
+				return err
+			}
+
+			ttl, ok := cc.routes[route]
+			if !ok {
+				return next(c)
+			}
+
+			userID, _ := auth.UserID(c.Request().Context())
+			key := c.Request().RequestURI + "|" + userID
+
+			if e, ok := cc.store.Get(key); ok {
+				c.Response().Header().Set("X-Cache", "HIT")
+				return respond(c, e, time.Until(e.Expires))
+			}
+
+			rec := &recorder{ResponseWriter: c.Response().Writer, status: http.StatusOK}
+			c.Response().Writer = rec
+			err := next(c)
+			c.Response().Writer = rec.ResponseWriter
+			c.Response().Committed = false
+
+			if err != nil || rec.status != http.StatusOK {
+				return rec.flush(c.Response(), err)
+			}
+
+			e := &Entry{
+				ContentType: c.Response().Header().Get(echo.HeaderContentType),
+				Body:        rec.body.Bytes(),
+				ETag:        ETag(rec.body.Bytes()),
+				Expires:     time.Now().Add(ttl),
+				Tags:        tags(rec.body.Bytes(), userID),
+			}
+			cc.store.Set(key, e)
+
+			c.Response().Header().Set("X-Cache", "MISS")
+			return respond(c, e, ttl)
+		}
+	}
+}
+
+// respond отвечает сохраненным ответом или 304, если у клиента та же версия.
+func respond(c echo.Context, e *Entry, maxAge time.Duration) error {
+	h := c.Response().Header()
+	h.Set("ETag", e.ETag)
+	h.Set("Cache-Control", "private, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	h.Add(echo.HeaderVary, echo.HeaderAuthorization)
+
+	if matchETag(c.Request().Header.Get("If-None-Match"), e.ETag) {
+		h.Del(echo.HeaderContentType)
+		h.Del(echo.HeaderContentLength)
+		c.Response().WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	h.Set(echo.HeaderContentLength, strconv.Itoa(len(e.Body)))
+	h.Set(echo.HeaderContentType, e.ContentType)
+	c.Response().WriteHeader(http.StatusOK)
+	_, err := c.Response().Write(e.Body)
+	return err
+}
+
+func matchETag(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// tags помечает ответ id пользователя, профиль которого в нем лежит,
+// и id того, кто его запросил: мутации вызвавшего сбрасывают и то, что
+// видел он сам (например, поиск после блокировки).
+func tags(body []byte, viewer string) []string {
+	var out []string
+	if viewer != "" {
+		out = append(out, userTag(viewer))
+	}
+
+	var profile struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(body, &profile) == nil && profile.ID != "" && profile.ID != viewer {
+		out = append(out, userTag(profile.ID))
+	}
+	return out
+}
+
+func userTag(id string) string {
+	return "user:" + id
+}
+
+// recorder придерживает ответ, чтобы до отправки добавить к нему ETag.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+// flush отправляет придержанный ответ, который не попал в кеш, как есть.
+// Если обработчик ничего не записал, ответ на ошибку сформирует Echo.
+func (r *recorder) flush(w *echo.Response, err error) error {
+	if r.body.Len() == 0 && err != nil {
+		return err
+	}
+	w.WriteHeader(r.status)
+	w.Write(r.body.Bytes())
+	return err
+}
@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/zura-t/go_messenger/api-gateway/internal/auth"
+)
+
+// testServer - gateway с кешем профилей. Вызвавший пользователь берется
+// из заголовка X-Test-User, как его передал бы middleware аутентификации.
+type testServer struct {
+	*echo.Echo
+	calls  int // вызовы бэкенда профиля
+	status int // статус ответа мутации
+}
+
+func newTestServer() *testServer {
+	s := &testServer{Echo: echo.New(), status: http.StatusOK}
+	s.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if id := c.Request().Header.Get("X-Test-User"); id != "" {
+				c.SetRequest(c.Request().WithContext(auth.WithUserID(c.Request().Context(), id)))
+			}
+			return next(c)
+		}
+	})
+	s.Use(New(NewMemory(100), Routes{"GET /profile": time.Minute}, "PATCH /profile").Middleware())
+
+	s.GET("/profile", func(c echo.Context) error {
+		s.calls++
+		id := c.QueryParam("id")
+		if id == "" {
+			id, _ = auth.UserID(c.Request().Context())
+		}
+		if id == "missing" {
+			return echo.NewHTTPError(http.StatusNotFound, "user not found")
+		}
+		return c.JSON(http.StatusOK, map[string]string{"id": id})
+	})
+	s.PATCH("/profile", func(c echo.Context) error {
+		return c.NoContent(s.status)
+	})
+	return s
+}
+
+func (s *testServer) do(method, target, user, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddlewareKeysPerUser(t *testing.T) {
+	s := newTestServer()
+
+	tests := []struct {
+		user, target string
+		cache        string
+		body         string
+	}{
+		{"1", "/profile", "MISS", `{"id":"1"}`},
+		{"1", "/profile", "HIT", `{"id":"1"}`},
+		// тот же URL другого пользователя - другая запись
+		{"2", "/profile", "MISS", `{"id":"2"}`},
+		{"2", "/profile", "HIT", `{"id":"2"}`},
+		{"2", "/profile?id=1", "MISS", `{"id":"1"}`},
+		{"1", "/profile?id=1", "MISS", `{"id":"1"}`},
+		{"", "/profile?id=1", "MISS", `{"id":"1"}`},
+		{"", "/profile?id=1", "HIT", `{"id":"1"}`},
+	}
+	for i, tt := range tests {
+		rec := s.do(http.MethodGet, tt.target, tt.user, "")
+		got := strings.TrimSpace(rec.Body.String())
+		if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != tt.cache || got != tt.body {
+			t.Errorf("%d: user %q GET %s = %d %s %s, want %s %s",
+				i, tt.user, tt.target, rec.Code, rec.Header().Get("X-Cache"), got, tt.cache, tt.body)
+		}
+	}
+	if s.calls != 5 {
+		t.Errorf("backend calls = %d, want 5", s.calls)
+	}
+}
+
+func TestMiddlewareConditional(t *testing.T) {
+	s := newTestServer()
+	first := s.do(http.MethodGet, "/profile", "1", "")
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag is not set")
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		status      int
+	}{
+		{"no validator", "", http.StatusOK},
+		{"same version", etag, http.StatusNotModified},
+		{"weak validator", "W/" + etag, http.StatusNotModified},
+		{"one of several", `"other", ` + etag, http.StatusNotModified},
+		{"any", "*", http.StatusNotModified},
+		{"other version", `"other"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(http.MethodGet, "/profile", "1", tt.ifNoneMatch)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status == http.StatusNotModified && rec.Body.Len() != 0 {
+				t.Errorf("304 has a body: %s", rec.Body)
+			}
+			h := rec.Header()
+			if h.Get("ETag") != etag {
+				t.Errorf("ETag = %q, want %q", h.Get("ETag"), etag)
+			}
+			// ответы разных пользователей различаются по токену
+			if h.Get(echo.HeaderVary) != echo.HeaderAuthorization {
+				t.Errorf("Vary = %q, want %q", h.Values(echo.HeaderVary), echo.HeaderAuthorization)
+			}
+			if cc := h.Get("Cache-Control"); cc != "private, max-age=60" && cc != "private, max-age=59" {
+				t.Errorf("Cache-Control = %q", cc)
+			}
+		})
+	}
+	if s.calls != 1 {
+		t.Errorf("backend calls = %d, want 1", s.calls)
+	}
+}
+
+func TestMiddlewareSkipsErrors(t *testing.T) {
+	s := newTestServer()
+	for range 2 {
+		rec := s.do(http.MethodGet, "/profile?id=missing", "1", "")
+		if rec.Code != http.StatusNotFound || rec.Header().Get("ETag") != "" {
+			t.Errorf("status = %d, ETag %q: errors must not be cached", rec.Code, rec.Header().Get("ETag"))
+		}
+	}
+	if s.calls != 2 {
+		t.Errorf("backend calls = %d, want 2", s.calls)
+	}
+}
+
+func TestMiddlewareMutationInvalidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		cleared bool
+	}{
+		{"success", http.StatusOK, true},
+		{"no content", http.StatusNoContent, true},
+		{"failure", http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer()
+			s.status = tt.status
+
+			// профиль 1, который видит он сам и другие, и чужие ответы
+			cached := []struct{ user, target string }{
+				{"1", "/profile"},
+				{"2", "/profile?id=1"},
+				{"", "/profile?id=1"},
+				{"1", "/profile?id=2"},
+			}
+			untouched := []struct{ user, target string }{
+				{"2", "/profile"},
+				{"3", "/profile?id=2"},
+			}
+			for _, r := range append(cached, untouched...) {
+				s.do(http.MethodGet, r.target, r.user, "")
+			}
+
+			if rec := s.do(http.MethodPatch, "/profile", "1", ""); rec.Code != tt.status {
+				t.Fatalf("PATCH status = %d, want %d", rec.Code, tt.status)
+			}
+
+			want := "HIT"
+			if tt.cleared {
+				want = "MISS"
+			}
+			for _, r := range cached {
+				if got := s.do(http.MethodGet, r.target, r.user, "").Header().Get("X-Cache"); got != want {
+					t.Errorf("user %q GET %s: X-Cache = %s, want %s", r.user, r.target, got, want)
+				}
+			}
+			for _, r := range untouched {
+				if got := s.do(http.MethodGet, r.target, r.user, "").Header().Get("X-Cache"); got != "HIT" {
+					t.Errorf("user %q GET %s: X-Cache = %s, want HIT", r.user, r.target, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// Страница собрана заново, а не взята из swagger-ui: ее ресурсы
+// подключаются по абсолютным путям, потому что gateway убирает
+// завершающий слеш, и относительные пути от /docs/ не работают.
+var uiPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>go_messenger API</title>
+  <link rel="stylesheet" href="{{.Assets}}/swagger-ui.css">
+  <link rel="icon" type="image/png" href="{{.Assets}}/favicon-32x32.png" sizes="32x32">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="{{.Assets}}/swagger-ui-bundle.js"></script>
+  <script src="{{.Assets}}/swagger-ui-standalone-preset.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({
+      url: "{{.Spec}}",
+      dom_id: "#swagger-ui",
+      presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+      layout: "StandaloneLayout"
+    });
+  </script>
+</body>
+</html>
+`))
+
+// uiPolicy разрешает странице Swagger UI ее скрипты и стили,
+// которые запрещает политика по умолчанию для ответов API.
+const uiPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:"
+
+// UI отдает страницу Swagger UI со спецификацией specURL;
+// ее ресурсы должны отдаваться Assets по пути assets.
+func UI(assets, specURL string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentSecurityPolicy, uiPolicy)
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+		return uiPage.Execute(c.Response(), struct{ Assets, Spec string }{assets, specURL})
+	}
+}
+
+// Assets отдает встроенные в бинарник файлы Swagger UI по пути prefix/*.
+func Assets(prefix string) echo.HandlerFunc {
+	return echo.WrapHandler(http.StripPrefix(prefix+"/", http.FileServer(http.FS(swaggerFiles.FS))))
+}
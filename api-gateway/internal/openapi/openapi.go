@@ -0,0 +1,230 @@
+// Package openapi собирает спецификацию OpenAPI 3 gateway: маршруты RPC
+// описываются по proto, остальные маршруты gateway описываются явно.
+package openapi
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/zura-t/go_messenger/api-gateway/internal/transcode"
+)
+
+const bearerAuth = "bearerAuth"
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem - операции пути по HTTP методу в нижнем регистре.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// New создает пустую спецификацию со схемой ошибки и bearer аутентификацией.
+func New(title, version string) *Document {
+	return &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: title, Version: version},
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: map[string]*Schema{
+				"Error": {
+					Type:       "object",
+					Properties: map[string]*Schema{"message": {Type: "string"}},
+				},
+			},
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+}
+
+// Add описывает маршрут method path, path - в синтаксисе Echo.
+// Ко всем операциям добавляется ответ с ошибкой.
+func (d *Document) Add(method, path string, op *Operation) {
+	if op.Responses == nil {
+		op.Responses = make(map[string]Response)
+	}
+	op.Responses["default"] = Response{
+		Description: "Error",
+		Content:     jsonContent(&Schema{Ref: "#/components/schemas/Error"}),
+	}
+	for _, name := range pathParams(path) {
+		if !hasParam(op.Parameters, name, "path") {
+			op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+
+	p := Path(path)
+	if d.Paths[p] == nil {
+		d.Paths[p] = make(PathItem)
+	}
+	d.Paths[p][strings.ToLower(method)] = op
+}
+
+// AddRoute описывает маршрут RPC: запрос, ответ и параметры берутся
+// из proto описания метода.
+func (d *Document) AddRoute(r transcode.Route) {
+	in, out := r.RPC.Input(), r.RPC.Output()
+
+	op := &Operation{
+		OperationID: string(r.RPC.Name()),
+		Summary:     string(r.RPC.Name()),
+		Tags:        []string{string(r.RPC.Parent().Name())},
+		Responses: map[string]Response{
+			"200": {Description: "OK", Content: jsonContent(d.message(out))},
+		},
+	}
+
+	inPath := make(map[string]bool)
+	for _, name := range pathParams(r.Path) {
+		inPath[name] = true
+		schema := &Schema{Type: "string"}
+		if fd := in.Fields().ByName(protoName(name)); fd != nil {
+			schema = d.field(fd)
+		}
+		op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+
+	switch r.Body {
+	case "":
+		fields := in.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			if inPath[string(fd.Name())] || fd.Message() != nil && !wellKnown(fd.Message()) || fd.IsMap() {
+				continue
+			}
+			op.Parameters = append(op.Parameters, Parameter{Name: fd.JSONName(), In: "query", Schema: d.field(fd)})
+		}
+	case "*":
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(d.message(in))}
+	default:
+		if fd := in.Fields().ByName(protoName(r.Body)); fd != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: jsonContent(d.field(fd))}
+		}
+	}
+
+	d.Add(r.Method, r.Path, op)
+}
+
+// Protect помечает операцию как требующую access токен.
+func (d *Document) Protect(method, path string) {
+	if op := d.Paths[Path(path)][strings.ToLower(method)]; op != nil {
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+}
+
+// Deprecate помечает операцию как устаревшую.
+func (d *Document) Deprecate(method, path string) {
+	if op := d.Paths[Path(path)][strings.ToLower(method)]; op != nil {
+		op.Deprecated = true
+	}
+}
+
+// Op описывает операцию с JSON ответом, схема которого выводится из типа v.
+func Op(summary string, v any) *Operation {
+	return &Operation{
+		Summary: summary,
+		Tags:    []string{"gateway"},
+		Responses: map[string]Response{
+			"200": {Description: "OK", Content: jsonContent(SchemaOf(v))},
+		},
+	}
+}
+
+// Raw описывает операцию, которая отвечает не JSON, а contentType.
+func Raw(summary, contentType string) *Operation {
+	return &Operation{
+		Summary: summary,
+		Tags:    []string{"gateway"},
+		Responses: map[string]Response{
+			"200": {Description: "OK", Content: map[string]MediaType{contentType: {Schema: &Schema{Type: "string"}}}},
+		},
+	}
+}
+
+var (
+	echoParam    = regexp.MustCompile(`:(\w+)`)
+	echoWildcard = regexp.MustCompile(`\*$`)
+)
+
+// Path переводит путь Echo в путь OpenAPI: /sessions/:id -> /sessions/{id},
+// /docs/* -> /docs/{path}.
+func Path(echoPath string) string {
+	p := echoParam.ReplaceAllString(echoPath, "{$1}")
+	return echoWildcard.ReplaceAllString(p, "{path}")
+}
+
+func pathParams(echoPath string) []string {
+	var names []string
+	for _, m := range echoParam.FindAllStringSubmatch(echoPath, -1) {
+		names = append(names, m[1])
+	}
+	if strings.HasSuffix(echoPath, "*") {
+		names = append(names, "path")
+	}
+	return names
+}
+
+func hasParam(params []Parameter, name, in string) bool {
+	for _, p := range params {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/zura-t/go_messenger/api-gateway/internal/auth"
+)
+
+// Routes относит маршруты к группам лимитов по ключу "МЕТОД путь",
+// как и auth.Policy. Маршруты одной группы делят ведро клиента.
+type Routes map[string]string
+
+// Limiter применяет лимиты групп к маршрутам.
+type Limiter struct {
+	backend Backend
+	routes  Routes
+	limits  map[string]Limit
+}
+
+// New создает Limiter. Группы без лимита в limits не ограничиваются.
+func New(backend Backend, routes Routes, limits map[string]Limit) *Limiter {
+	return &Limiter{backend: backend, routes: routes, limits: limits}
+}
+
+// Middleware должен стоять после middleware аутентификации: ведро
+// аутентифицированного клиента выбирается по id пользователя, остальных - по IP.
+// Если бэкенд недоступен, запрос пропускается.
+func (l *Limiter) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			group, ok := l.routes[c.Request().Method+" "+c.Path()]
+			if !ok {
+				return next(c)
+			}
+			limit, ok := l.limits[group]
+			if !ok {
+				return next(c)
+			}
+
+			res, err := l.backend.Take(c.Request().Context(), group+":"+clientKey(c), limit)
+			if err != nil {
+				c.Logger().Errorf("ratelimit: %v", err)
+				return next(c)
+			}
+
+			h := c.Response().Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("X-RateLimit-Reset", ceilSeconds(res.Reset))
+
+			if !res.Allowed {
+				h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+				return echo.NewHTTPError(http.StatusTooManyRequests, "too many requests")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func clientKey(c echo.Context) string {
+	if id, ok := auth.UserID(c.Request().Context()); ok {
+		return "user:" + id
+	}
+	return "ip:" + c.RealIP()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,77 @@
+// Package ratelimit ограничивает частоту запросов к группам маршрутов
+// алгоритмом token bucket. Ведро заводится на пару (группа, клиент),
+// клиент - аутентифицированный пользователь или IP адрес.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit - параметры ведра: Burst запросов подряд, затем Rate запросов в секунду.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseLimit разбирает лимит вида "5/1m": не больше 5 запросов в минуту.
+// Пустая строка означает, что лимита нет.
+func ParseLimit(s string) (Limit, bool, error) {
+	if s == "" {
+		return Limit{}, false, nil
+	}
+
+	count, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, false, fmt.Errorf("rate limit %q must look like 5/1m", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return Limit{}, false, fmt.Errorf("rate limit %q: request count must be a positive number", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, false, fmt.Errorf("rate limit %q: period must be a positive duration", s)
+	}
+
+	return Limit{Rate: float64(n) / d.Seconds(), Burst: n}, true, nil
+}
+
+// Result - итог попытки взять токен из ведра.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter - через сколько появится следующий токен, если запрос отклонен.
+	RetryAfter time.Duration
+	// Reset - через сколько ведро снова заполнится целиком.
+	Reset time.Duration
+}
+
+// Backend хранит ведра. Memory подходит для одной реплики gateway,
+// Redis делит лимиты между всеми репликами.
+type Backend interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// result считает Result по числу токенов, оставшихся в ведре.
+func result(limit Limit, tokens float64, allowed bool) Result {
+	r := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		r.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  Limit
+		ok    bool
+		isErr bool
+	}{
+		{"", Limit{}, false, false},
+		{"5/1m", Limit{Rate: 5.0 / 60, Burst: 5}, true, false},
+		{"10/1s", Limit{Rate: 10, Burst: 10}, true, false},
+		{"5", Limit{}, false, true},
+		{"0/1m", Limit{}, false, true},
+		{"-1/1m", Limit{}, false, true},
+		{"5/0s", Limit{}, false, true},
+		{"5/minute", Limit{}, false, true},
+	}
+	for _, tt := range tests {
+		got, ok, err := ParseLimit(tt.in)
+		if (err != nil) != tt.isErr || ok != tt.ok || got != tt.want {
+			t.Errorf("ParseLimit(%q) = %v, %v, %v", tt.in, got, ok, err)
+		}
+	}
+}
+
+// take - попытка взять токен через advance после предыдущей и ее итог.
+type take struct {
+	advance    time.Duration
+	allowed    bool
+	remaining  int
+	retryAfter time.Duration
+}
+
+// testBackend проверяет token bucket на backend; advance переводит его часы.
+func testBackend(t *testing.T, b Backend, advance func(time.Duration)) {
+	ctx := context.Background()
+	limit := Limit{Rate: 2, Burst: 3}
+
+	takes := []take{
+		// ведро начинается полным: burst запросов подряд
+		{allowed: true, remaining: 2},
+		{allowed: true, remaining: 1},
+		{allowed: true, remaining: 0},
+		{allowed: false, remaining: 0, retryAfter: 500 * time.Millisecond},
+		// за 250ms набирается полтокена - мало
+		{advance: 250 * time.Millisecond, allowed: false, remaining: 0, retryAfter: 250 * time.Millisecond},
+		{advance: 250 * time.Millisecond, allowed: true, remaining: 0},
+		// ведро не наполняется больше burst
+		{advance: time.Hour, allowed: true, remaining: 2},
+	}
+	for i, tk := range takes {
+		advance(tk.advance)
+		r, err := b.Take(ctx, "login:1.2.3.4", limit)
+		if err != nil {
+			t.Fatalf("take %d: %v", i, err)
+		}
+		if r.Allowed != tk.allowed || r.Remaining != tk.remaining || r.Limit != limit.Burst {
+			t.Fatalf("take %d: %+v, want allowed %v, remaining %d", i, r, tk.allowed, tk.remaining)
+		}
+		if d := r.RetryAfter - tk.retryAfter; d < -time.Millisecond || d > time.Millisecond {
+			t.Fatalf("take %d: retry after %v, want %v", i, r.RetryAfter, tk.retryAfter)
+		}
+	}
+
+	// у другого клиента свое ведро
+	r, err := b.Take(ctx, "login:5.6.7.8", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Allowed || r.Remaining != limit.Burst-1 {
+		t.Fatalf("other key: %+v, want a full bucket", r)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	testBackend(t, m, func(d time.Duration) { now = now.Add(d) })
+}
+
+func TestRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mr.SetTime(now)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	testBackend(t, NewRedis(client, "rl:"), func(d time.Duration) {
+		now = now.Add(d)
+		mr.SetTime(now)
+	})
+
+	// ключ живет, пока ведро не наполнится, и еще секунду
+	if _, err := NewRedis(client, "rl:").Take(context.Background(), "ttl", Limit{Rate: 1, Burst: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL("rl:ttl"); ttl != 2*time.Second {
+		t.Errorf("ttl = %v, want 2s", ttl)
+	}
+}
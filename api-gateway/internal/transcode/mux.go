@@ -0,0 +1,83 @@
+// Package transcode публикует RPC сервисов как REST: маршруты и перевод
+// JSON <-> proto берутся из аннотаций google.api.http в proto файлах,
+// сам перевод выполняет сгенерированный grpc-gateway код.
+package transcode
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/zura-t/go_messenger/api-gateway/internal/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewMux создает grpc-gateway mux. JSON поля называются по json_name,
+// неизвестные поля в запросе игнорируются.
+func NewMux() *runtime.ServeMux {
+	return runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithMetadata(forwardedMetadata),
+		runtime.WithErrorHandler(errorHandler),
+	)
+}
+
+// forwardedMetadata передает user agent клиента, который accounts сохраняет
+// в сессии, и id пользователя, проверенный middleware аутентификации.
+// Authorization и X-Forwarded-For grpc-gateway передает сам.
+func forwardedMetadata(_ context.Context, r *http.Request) metadata.MD {
+	md := metadata.Pairs("x-forwarded-user-agent", r.UserAgent())
+	if id := r.Header.Get(auth.UserIDHeader); id != "" {
+		md.Set("x-user-id", id)
+	}
+	return md
+}
+
+// errorHandler отвечает в том же формате, что и ошибки Echo: {"message": "..."}.
+func errorHandler(_ context.Context, _ *runtime.ServeMux, m runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+	st := status.Convert(err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(HTTPStatus(st.Code()))
+
+	body, _ := (&runtime.JSONBuiltin{}).Marshal(map[string]string{"message": st.Message()})
+	w.Write(body)
+}
+
+// HTTPStatus переводит код gRPC в HTTP статус ответа.
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		// FailedPrecondition - состояние ресурса, а не заголовки If-*,
+		// поэтому не 412, как и в grpc-gateway
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return 499
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,85 @@
+package transcode
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Route - HTTP маршрут, объявленный у RPC аннотацией google.api.http.
+type Route struct {
+	Method string
+	// Path в синтаксисе Echo: /accounts/sessions/:id
+	Path string
+	// Body - поле запроса, которое передается телом: "*" - весь запрос,
+	// пусто - тела нет, поля запроса передаются в пути и параметрах запроса.
+	Body string
+	RPC  protoreflect.MethodDescriptor
+}
+
+var pathParam = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+// Routes возвращает маршруты всех RPC сервиса, у которых есть аннотация.
+func Routes(sd protoreflect.ServiceDescriptor) []Route {
+	var routes []Route
+
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+
+		rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+
+		for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+			method, pattern := httpPattern(r)
+			if pattern == "" {
+				continue
+			}
+			routes = append(routes, Route{
+				Method: method,
+				Path:   pathParam.ReplaceAllString(pattern, ":$1"),
+				Body:   r.GetBody(),
+				RPC:    md,
+			})
+		}
+	}
+
+	return routes
+}
+
+// Mount регистрирует маршруты сервиса в e; все они обслуживаются mux,
+// в котором зарегистрирован сгенерированный обработчик сервиса.
+// Отдельный маршрут Echo на каждый RPC позволяет навешивать на них middleware.
+func Mount(e *echo.Echo, mux http.Handler, sd protoreflect.ServiceDescriptor, m ...echo.MiddlewareFunc) []Route {
+	routes := Routes(sd)
+	handler := echo.WrapHandler(mux)
+	for _, r := range routes {
+		e.Add(r.Method, r.Path, handler, m...)
+	}
+	return routes
+}
+
+func httpPattern(r *annotations.HttpRule) (string, string) {
+	switch p := r.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, p.Get
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, p.Post
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, p.Put
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Custom:
+		return p.Custom.GetKind(), p.Custom.GetPath()
+	default:
+		return "", ""
+	}
+}
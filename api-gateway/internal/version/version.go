@@ -0,0 +1,170 @@
+// Package version - версии REST API gateway. Маршруты API публикуются
+// под префиксом версии (/v1/register), и несколько версий обслуживаются
+// рядом. Устаревшие версии и маршруты отвечают с заголовками Deprecation
+// и Sunset, а после Sunset и для удаленных версий - ошибкой 410.
+package version
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Lifecycle - сроки вывода версии или маршрута из эксплуатации.
+// Нулевое значение - версия не устарела.
+type Lifecycle struct {
+	// Deprecated - с какого момента версия устарела, отправляется в Deprecation.
+	Deprecated time.Time
+	// Sunset - после этого момента запросы отклоняются с 410 Gone.
+	Sunset time.Time
+	// Link - документ о переходе на новую версию.
+	Link string
+}
+
+// Policy описывает версии API.
+type Policy struct {
+	// Versions - обслуживаемые версии ("v1") и их сроки.
+	Versions map[string]Lifecycle
+	// Removed - удаленные версии, запросы к ним получают 410.
+	Removed []string
+	// Routes - отдельные устаревшие маршруты "METHOD /v1/path".
+	Routes map[string]Lifecycle
+
+	// Legacy - версия, которой обслуживаются пути API без префикса
+	// версии: по ним ходят клиенты, выпущенные до появления версий.
+	Legacy          string
+	LegacyLifecycle Lifecycle
+	// Unversioned - пути, которые не относятся к API и не имеют версии,
+	// вместе с вложенными: /health, /docs.
+	Unversioned []string
+}
+
+// Error - тело ответа на запрос к удаленной или неизвестной версии.
+type Error struct {
+	Message string `json:"message"`
+	// Code - version_removed, version_unsupported или route_removed.
+	Code      string   `json:"code"`
+	Version   string   `json:"version,omitempty"`
+	Supported []string `json:"supported"`
+}
+
+var prefix = regexp.MustCompile(`^/(v[0-9]+)(/|$)`)
+
+const legacyKey = "version.legacy"
+
+// Rewrite переводит пути без версии на версию Legacy. Он должен быть
+// подключен через Echo.Pre: путь меняется до выбора маршрута.
+func (p Policy) Rewrite() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			u := c.Request().URL
+			if p.Legacy == "" || prefix.MatchString(u.Path) || p.unversioned(u.Path) {
+				return next(c)
+			}
+
+			u.Path = "/" + p.Legacy + u.Path
+			if u.RawPath != "" {
+				u.RawPath = "/" + p.Legacy + u.RawPath
+			}
+			c.Set(legacyKey, true)
+			return next(c)
+		}
+	}
+}
+
+// Middleware отклоняет запросы к удаленным и неизвестным версиям и
+// добавляет Deprecation и Sunset к ответам устаревших версий и маршрутов.
+// Он должен стоять после CORS, чтобы браузер мог прочитать ошибку.
+func (p Policy) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			m := prefix.FindStringSubmatch(c.Request().URL.Path)
+			if m == nil {
+				return next(c)
+			}
+			v := m[1]
+
+			if slices.Contains(p.Removed, v) {
+				return p.error(http.StatusGone, "version_removed", v, fmt.Sprintf("API version %s has been removed", v))
+			}
+			lifecycle, ok := p.Versions[v]
+			if !ok {
+				return p.error(http.StatusNotFound, "version_unsupported", v, fmt.Sprintf("API version %s is not supported", v))
+			}
+
+			if legacy, _ := c.Get(legacyKey).(bool); legacy {
+				lifecycle = p.LegacyLifecycle
+			}
+			if route, ok := p.Routes[c.Request().Method+" "+c.Path()]; ok {
+				lifecycle = route
+			}
+			if err := p.apply(c, lifecycle); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// Deprecated сообщает, устарел ли маршрут "METHOD /v1/path" или его версия.
+func (p Policy) Deprecated(route string) bool {
+	if l, ok := p.Routes[route]; ok {
+		return !l.Deprecated.IsZero()
+	}
+	_, path, _ := strings.Cut(route, " ")
+	if m := prefix.FindStringSubmatch(path); m != nil {
+		return !p.Versions[m[1]].Deprecated.IsZero()
+	}
+	return false
+}
+
+// apply добавляет заголовки RFC 9745 и RFC 8594, а после Sunset
+// отклоняет запрос.
+func (p Policy) apply(c echo.Context, l Lifecycle) error {
+	h := c.Response().Header()
+	if !l.Deprecated.IsZero() {
+		h.Set("Deprecation", fmt.Sprintf("@%d", l.Deprecated.Unix()))
+	}
+	if !l.Sunset.IsZero() {
+		h.Set("Sunset", l.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if l.Link != "" {
+		h.Add("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"", l.Link))
+	}
+
+	if !l.Sunset.IsZero() && !time.Now().Before(l.Sunset) {
+		return p.error(http.StatusGone, "route_removed", "", "this route has been removed, use a newer API version")
+	}
+	return nil
+}
+
+func (p Policy) error(status int, code, version, message string) error {
+	supported := make([]string, 0, len(p.Versions))
+	for v, l := range p.Versions {
+		if l.Sunset.IsZero() || time.Now().Before(l.Sunset) {
+			supported = append(supported, v)
+		}
+	}
+	slices.Sort(supported)
+
+	return echo.NewHTTPError(status, Error{
+		Message:   message,
+		Code:      code,
+		Version:   version,
+		Supported: supported,
+	})
+}
+
+func (p Policy) unversioned(path string) bool {
+	for _, u := range p.Unversioned {
+		if path == u || strings.HasPrefix(path, u+"/") {
+			return true
+		}
+	}
+	return false
+}
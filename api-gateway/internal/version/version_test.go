@@ -0,0 +1,145 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	now        = time.Now().Truncate(time.Second)
+	deprecated = time.Date(2026, time.January, 2, 3, 4, 5, 0, time.UTC)
+	sunset     = now.Add(30 * 24 * time.Hour)
+)
+
+var policy = Policy{
+	Versions: map[string]Lifecycle{
+		"v1": {Deprecated: deprecated, Sunset: sunset, Link: "/docs/v2"},
+		"v2": {},
+		"v3": {Deprecated: deprecated, Sunset: now.Add(-time.Hour)},
+	},
+	Removed: []string{"v0"},
+	Routes: map[string]Lifecycle{
+		// маршрут выведен раньше своей версии
+		"GET /v2/old": {Deprecated: deprecated, Sunset: now.Add(-time.Hour)},
+		// маршрут устаревшей версии, который еще поддерживается
+		"GET /v1/kept": {},
+	},
+	Legacy:          "v1",
+	LegacyLifecycle: Lifecycle{Deprecated: deprecated.Add(time.Hour), Sunset: sunset.Add(time.Hour), Link: "/docs"},
+	Unversioned:     []string{"/health", "/docs"},
+}
+
+func newServer() *echo.Echo {
+	e := echo.New()
+	e.Pre(policy.Rewrite())
+	e.Use(policy.Middleware())
+
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Path()+" "+c.Param("id"))
+	}
+	for _, path := range []string{"/v1/users/:id", "/v2/users/:id", "/v3/users/:id", "/v2/old", "/v1/kept", "/health", "/docs/*"} {
+		e.GET(path, handler)
+	}
+	return e
+}
+
+func TestPolicy(t *testing.T) {
+	e := newServer()
+
+	v1 := header{
+		deprecation: "@1767323045",
+		sunset:      sunset.UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT"),
+		link:        `</docs/v2>; rel="deprecation"`,
+	}
+	legacy := header{
+		deprecation: "@1767326645",
+		sunset:      sunset.Add(time.Hour).UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT"),
+		link:        `</docs>; rel="deprecation"`,
+	}
+	tests := []struct {
+		name   string
+		target string
+		status int
+		body   string // маршрут и id, которые увидел обработчик
+		code   string // код ошибки в теле
+		header header
+	}{
+		{"current version", "/v2/users/7", http.StatusOK, "/v2/users/:id 7", "", header{}},
+		{"deprecated version", "/v1/users/7", http.StatusOK, "/v1/users/:id 7", "", v1},
+		{"legacy path", "/users/7", http.StatusOK, "/v1/users/:id 7", "", legacy},
+		// экранированный слеш остается частью id
+		{"legacy raw path", "/users/a%2Fb", http.StatusOK, "/v1/users/:id a%2Fb", "", legacy},
+		{"unversioned", "/health", http.StatusOK, "/health ", "", header{}},
+		{"unversioned nested", "/docs/index.html", http.StatusOK, "/docs/* ", "", header{}},
+		{"route kept in deprecated version", "/v1/kept", http.StatusOK, "/v1/kept ", "", header{}},
+		{"removed version", "/v0/users/7", http.StatusGone, "", "version_removed", header{}},
+		{"after version sunset", "/v3/users/7", http.StatusGone, "", "route_removed", header{
+			deprecation: "@1767323045",
+			sunset:      now.Add(-time.Hour).UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT"),
+		}},
+		{"after route sunset", "/v2/old", http.StatusGone, "", "route_removed", header{
+			deprecation: "@1767323045",
+			sunset:      now.Add(-time.Hour).UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT"),
+		}},
+		{"unknown version", "/v9/users/7", http.StatusNotFound, "", "version_unsupported", header{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.code == "" {
+				if rec.Body.String() != tt.body {
+					t.Errorf("handler got %q, want %q", rec.Body, tt.body)
+				}
+			} else {
+				var got Error
+				if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+					t.Fatalf("decode error: %v: %s", err, rec.Body)
+				}
+				// v3 уже выведена и в списке не указывается
+				if got.Code != tt.code || !slices.Equal(got.Supported, []string{"v1", "v2"}) {
+					t.Errorf("error = %+v, want code %s, supported [v1 v2]", got, tt.code)
+				}
+			}
+
+			h := rec.Header()
+			got := header{h.Get("Deprecation"), h.Get("Sunset"), h.Get("Link")}
+			if got != tt.header {
+				t.Errorf("headers = %+v, want %+v", got, tt.header)
+			}
+		})
+	}
+}
+
+// header - заголовки Deprecation, Sunset и Link ответа.
+type header struct {
+	deprecation, sunset, link string
+}
+
+func TestDeprecated(t *testing.T) {
+	tests := []struct {
+		route string
+		want  bool
+	}{
+		{"GET /v1/users/:id", true},
+		{"GET /v2/users/:id", false},
+		{"GET /v2/old", true},
+		{"GET /v1/kept", false},
+		{"GET /health", false},
+	}
+	for _, tt := range tests {
+		if got := policy.Deprecated(tt.route); got != tt.want {
+			t.Errorf("Deprecated(%q) = %v, want %v", tt.route, got, tt.want)
+		}
+	}
+}
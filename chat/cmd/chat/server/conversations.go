@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zura-t/go_messenger/chat/internal/messages"
+	pb "github.com/zura-t/go_messenger/chat/pkg/chat"
+	rpb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const maxTitleLength = 100
+
+func (s *server) StartGroupConversation(ctx context.Context, req *pb.StartGroupConversationRequest) (*pb.Conversation, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetGroupId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "group_id is required")
+	}
+	title := strings.TrimSpace(req.GetTitle())
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		return nil, status.Errorf(codes.InvalidArgument, "title must not exceed %d characters", maxTitleLength)
+	}
+
+	// группа принадлежит вызвавшему, поэтому relations спрашивается от его имени
+	ctx = metadata.AppendToOutgoingContext(ctx, userIDHeader, strconv.FormatUint(user, 10))
+	group, err := s.relations.GetGroup(ctx, &rpb.GetGroupRequest{GroupId: req.GetGroupId()})
+	if status.Code(err) == codes.NotFound {
+		return nil, status.Error(codes.NotFound, "group not found")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, "failed to get the group")
+	}
+	if len(group.GetMemberIds()) == 0 {
+		return nil, status.Error(codes.FailedPrecondition, "group has no members")
+	}
+	if title == "" {
+		title = group.GetName()
+	}
+
+	return conversationToProto(s.messages.StartConversation(user, title, group.GetMemberIds())), nil
+}
+
+func (s *server) ListConversations(ctx context.Context, _ *pb.ListConversationsRequest) (*pb.ListConversationsResponse, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	direct := s.messages.Last(user)
+	groups := s.messages.Conversations(user)
+	resp := &pb.ListConversationsResponse{Conversations: make([]*pb.Conversation, 0, len(direct)+len(groups))}
+	for _, m := range direct {
+		resp.Conversations = append(resp.Conversations, &pb.Conversation{
+			PeerId:      m.Peer(user),
+			LastMessage: toProto(m),
+			ReadOnly:    s.messages.ReadOnly(user, m.Peer(user)),
+		})
+	}
+	for _, c := range groups {
+		conv := conversationToProto(c)
+		if m, ok := s.messages.LastPost(c.ID); ok {
+			conv.LastMessage = toProto(m)
+		}
+		resp.Conversations = append(resp.Conversations, conv)
+	}
+	slices.SortStableFunc(resp.Conversations, func(a, b *pb.Conversation) int {
+		return activity(b).Compare(activity(a))
+	})
+	return resp, nil
+}
+
+// activity - время последнего сообщения переписки, а у групповой без
+// сообщений - время ее создания.
+func activity(c *pb.Conversation) time.Time {
+	if c.GetLastMessage() != nil {
+		return c.GetLastMessage().GetSentAt().AsTime()
+	}
+	return c.GetCreatedAt().AsTime()
+}
+
+// conversation возвращает групповую переписку, в которой участвует user.
+// Чужая переписка для него не существует. Если user и кто-то из
+// участников заблокировал другого, user в ней не пишет и не читает ее.
+func (s *server) conversation(ctx context.Context, user, id uint64) (messages.Conversation, error) {
+	c, ok := s.messages.Conversation(id)
+	if !ok || !c.Member(user) {
+		return messages.Conversation{}, status.Error(codes.NotFound, "conversation not found")
+	}
+
+	for _, member := range c.Members {
+		if member == user {
+			continue
+		}
+		// как и для личных сообщений, без ответа relations переписка закрыта
+		relation, err := s.policy.Check(ctx, user, member)
+		if err != nil {
+			return messages.Conversation{}, status.Error(codes.Unavailable, "failed to check the relations with the conversation members")
+		}
+		if relation.Blocked() {
+			return messages.Conversation{}, status.Error(codes.PermissionDenied, "the conversation has a member blocked by or blocking the caller")
+		}
+	}
+	return c, nil
+}
+
+func conversationToProto(c messages.Conversation) *pb.Conversation {
+	return &pb.Conversation{
+		Id:        c.ID,
+		Title:     c.Title,
+		CreatedBy: c.CreatedBy,
+		MemberIds: c.Members,
+		CreatedAt: timestamppb.New(c.CreatedAt),
+		ReadOnly:  c.ReadOnly,
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_messenger/chat/internal/messages"
+	pb "github.com/zura-t/go_messenger/chat/pkg/chat"
+	"github.com/zura-t/go_messenger/relations/pkg/policy"
+	rpb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeRelations отвечает на CheckRelation: пары из blocked заблокированы,
+// остальные - друзья.
+type fakeRelations struct {
+	rpb.RelationsServiceClient
+	blocked [][2]uint64
+	err     error
+}
+
+func (f fakeRelations) CheckRelation(_ context.Context, req *rpb.CheckRelationRequest, _ ...grpc.CallOption) (*rpb.CheckRelationResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, p := range f.blocked {
+		if p == [2]uint64{req.GetUserId(), req.GetTargetId()} || p == [2]uint64{req.GetTargetId(), req.GetUserId()} {
+			return &rpb.CheckRelationResponse{State: rpb.RelationState_RELATION_STATE_BLOCKED}, nil
+		}
+	}
+	return &rpb.CheckRelationResponse{State: rpb.RelationState_RELATION_STATE_FRIEND}, nil
+}
+
+func as(user uint64) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(userIDHeader, strconv.FormatUint(user, 10)))
+}
+
+func TestGroupConversationBlocks(t *testing.T) {
+	tests := []struct {
+		name      string
+		relations fakeRelations
+		user      uint64
+		want      codes.Code
+	}{
+		{"no blocks", fakeRelations{}, 1, codes.OK},
+		{"caller blocked a member", fakeRelations{blocked: [][2]uint64{{1, 3}}}, 1, codes.PermissionDenied},
+		{"member blocked the caller", fakeRelations{blocked: [][2]uint64{{3, 1}}}, 1, codes.PermissionDenied},
+		{"block between other members", fakeRelations{blocked: [][2]uint64{{2, 3}}}, 1, codes.OK},
+		{"relations unavailable", fakeRelations{err: errors.New("connection refused")}, 1, codes.Unavailable},
+		{"not a member", fakeRelations{}, 4, codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := messages.NewStore()
+			conv := store.StartConversation(1, "team", []uint64{2, 3})
+			s := NewServer(store, tt.relations, policy.New(tt.relations, time.Minute, 100))
+
+			_, err := s.SendMessage(as(tt.user), &pb.SendMessageRequest{ConversationId: conv.ID, Text: "hi"})
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("SendMessage: code = %v, want %v (%v)", got, tt.want, err)
+			}
+			_, err = s.ListMessages(as(tt.user), &pb.ListMessagesRequest{ConversationId: conv.ID})
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("ListMessages: code = %v, want %v (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRelationEventsLockConversations(t *testing.T) {
+	store := messages.NewStore()
+	s := NewServer(store, fakeRelations{}, policy.New(fakeRelations{}, time.Minute, 100))
+	if _, err := s.SendMessage(as(1), &pb.SendMessageRequest{UserId: 2, Text: "hi"}); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	group := store.StartConversation(1, "team", []uint64{2, 3})
+
+	event := func(typ rpb.RelationEventType, actor, target uint64) {
+		s.relationChanged(&rpb.RelationEvent{Type: typ, ActorId: actor, TargetId: target})
+	}
+	readOnly := func() (direct, group bool) {
+		resp, err := s.ListConversations(as(1), &pb.ListConversationsRequest{})
+		if err != nil {
+			t.Fatalf("ListConversations: %v", err)
+		}
+		for _, c := range resp.GetConversations() {
+			if c.GetId() != 0 {
+				group = c.GetReadOnly()
+			} else {
+				direct = c.GetReadOnly()
+			}
+		}
+		return direct, group
+	}
+
+	event(rpb.RelationEventType_RELATION_EVENT_TYPE_FRIENDSHIP_REMOVED, 2, 1)
+	if direct, group := readOnly(); !direct || group {
+		t.Errorf("after removal: read_only direct = %v, group = %v, want true, false", direct, group)
+	}
+	event(rpb.RelationEventType_RELATION_EVENT_TYPE_REQUEST_ACCEPTED, 1, 2)
+	if direct, _ := readOnly(); direct {
+		t.Error("after accept: direct conversation is still read-only")
+	}
+
+	// блок между другими участниками закрывает группу и для вызвавшего
+	event(rpb.RelationEventType_RELATION_EVENT_TYPE_USER_BLOCKED, 3, 2)
+	if direct, group := readOnly(); direct || !group {
+		t.Errorf("after block: read_only direct = %v, group = %v, want false, true", direct, group)
+	}
+	_, err := s.SendMessage(as(1), &pb.SendMessageRequest{ConversationId: group.ID, Text: "hi"})
+	if got := status.Code(err); got != codes.FailedPrecondition {
+		t.Errorf("SendMessage to a read-only group: code = %v, want %v", got, codes.FailedPrecondition)
+	}
+	if _, err := s.ListMessages(as(1), &pb.ListMessagesRequest{ConversationId: group.ID}); err != nil {
+		t.Errorf("ListMessages of a read-only group: %v", err)
+	}
+}
+
+func TestSendMessageCorrectsReadOnly(t *testing.T) {
+	store := messages.NewStore()
+	store.Add(1, 2, "hi")
+	store.SetReadOnly(1, 2, true)
+
+	// событие о новой дружбе потеряно, но relations отвечает, что они друзья
+	s := NewServer(store, fakeRelations{}, policy.New(fakeRelations{}, time.Minute, 100))
+	if _, err := s.SendMessage(as(2), &pb.SendMessageRequest{UserId: 1, Text: "hi"}); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if store.ReadOnly(1, 2) {
+		t.Error("conversation of friends is still read-only")
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	rpb "github.com/zura-t/go_messenger/relations/pkg/relations"
+)
+
+// relationChanged закрывает переписки, которые событие relations делает
+// доступными только для чтения, и снова открывает личную переписку новых
+// друзей. Пока поток событий прерван, пометки личных переписок поправляет
+// SendMessage по ответу CheckRelation.
+func (s *server) relationChanged(e *rpb.RelationEvent) {
+	a, b := e.GetActorId(), e.GetTargetId()
+	switch e.GetType() {
+	case rpb.RelationEventType_RELATION_EVENT_TYPE_FRIENDSHIP_REMOVED:
+		s.messages.SetReadOnly(a, b, true)
+	case rpb.RelationEventType_RELATION_EVENT_TYPE_USER_BLOCKED:
+		s.messages.SetReadOnly(a, b, true)
+		s.messages.LockConversations(a, b)
+	case rpb.RelationEventType_RELATION_EVENT_TYPE_REQUEST_ACCEPTED:
+		s.messages.SetReadOnly(a, b, false)
+	}
+}
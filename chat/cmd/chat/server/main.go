@@ -0,0 +1,109 @@
+package main
+
+import (
+	"log"
+	"log/slog"
+	"net"
+	"os"
+	"time"
+
+	"github.com/zura-t/go_messenger/chat/internal/messages"
+	pb "github.com/zura-t/go_messenger/chat/pkg/chat"
+	"github.com/zura-t/go_messenger/platform/config"
+	"github.com/zura-t/go_messenger/platform/grpcpool"
+	"github.com/zura-t/go_messenger/platform/health"
+	"github.com/zura-t/go_messenger/platform/lifecycle"
+	"github.com/zura-t/go_messenger/platform/resilience"
+	"github.com/zura-t/go_messenger/platform/servicetoken"
+	"github.com/zura-t/go_messenger/relations/pkg/policy"
+	rpb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+const (
+	// healthInterval - как часто проверяется relations для grpc.health.v1
+	healthInterval = 5 * time.Second
+	healthTimeout  = 2 * time.Second
+)
+
+func main() {
+	defaults := config.Default()
+	defaults.GRPC.Addr = ":8084"
+	defaults.Endpoints.Relations = "localhost:8083"
+
+	cfg, err := config.Load(defaults, os.Args[1:])
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if err := cfg.Require("grpc.addr", "endpoints.relations"); err != nil {
+		log.Fatal(err)
+	}
+	slog.SetLogLoggerLevel(cfg.Log.SlogLevel())
+	log.Printf("config:\n%s", cfg)
+
+	lis, err := net.Listen("tcp", cfg.GRPC.Addr)
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+
+	clientCreds := insecure.NewCredentials()
+	if cfg.TLS.CAFile != "" {
+		clientCreds, err = credentials.NewClientTLSFromFile(cfg.TLS.CAFile, "")
+		if err != nil {
+			log.Fatalf("failed to load CA certificate: %v", err)
+		}
+	}
+
+	// relations проверяет, дружат ли отправитель и получатель
+	relationsPolicy := resilience.New("relations", cfg.Backends.Relations)
+	relationsConn, err := grpcpool.New(cfg.Endpoints.Relations, cfg.Endpoints.PoolSize,
+		append(relationsPolicy.DialOptions(), grpc.WithTransportCredentials(clientCreds), servicetoken.Attach(cfg.Tokens.ServiceToken))...)
+	if err != nil {
+		log.Fatalf("failed to create relations client: %v", err)
+	}
+	defer relationsConn.Close()
+
+	var opts []grpc.ServerOption
+	if cfg.TLS.Enabled() {
+		creds, err := credentials.NewServerTLSFromFile(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			log.Fatalf("failed to load TLS certificate: %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	ctx, stop := lifecycle.SignalContext()
+	defer stop()
+
+	relations := rpb.NewRelationsServiceClient(relationsConn)
+	relationsCache := policy.New(relations, cfg.Relations.CheckCacheTTL, cfg.Relations.CheckCacheSize)
+	chat := NewServer(messages.NewStore(), relations, relationsCache)
+	// одна подписка на события и сбрасывает кеш, и закрывает переписки
+	go relationsCache.Run(ctx, chat.relationChanged)
+
+	server := grpc.NewServer(opts...)
+	pb.RegisterChatServiceServer(server, chat)
+
+	// для readiness проверок: api-gateway и kubernetes спрашивают grpc.health.v1
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	// без relations сообщения не отправить: chat не готов, пока он недоступен
+	checker := health.NewChecker(healthTimeout)
+	checker.Add("relations", health.GRPC(relationsConn))
+	checker.Add("relations.breaker", relationsPolicy.Check)
+	go checker.RunGRPC(ctx, healthServer, healthInterval)
+
+	reflection.Register(server)
+
+	log.Printf("server listening at %v", lis.Addr())
+	if err := lifecycle.ServeGRPC(ctx, server, lis, cfg.Shutdown.Timeout, healthServer.Shutdown); err != nil {
+		log.Fatalf("failed to serve: %v", err)
+	}
+}
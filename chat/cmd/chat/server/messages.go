@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/zura-t/go_messenger/chat/internal/messages"
+	pb "github.com/zura-t/go_messenger/chat/pkg/chat"
+	"github.com/zura-t/go_messenger/relations/pkg/policy"
+	rpb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// userIDHeader - метаданные, в которых api-gateway передает публичный id
+// пользователя, проверенный по его access токену.
+const userIDHeader = "x-user-id"
+
+const (
+	maxTextLength   = 4000
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+type server struct {
+	pb.UnimplementedChatServiceServer
+
+	messages  *messages.Store
+	relations rpb.RelationsServiceClient
+	// policy - связи отправителей с получателями, кешированные до их изменения
+	policy *policy.Cache
+}
+
+func NewServer(messages *messages.Store, relations rpb.RelationsServiceClient, policy *policy.Cache) *server {
+	return &server{messages: messages, relations: relations, policy: policy}
+}
+
+func (s *server) SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*pb.Message, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case req.GetConversationId() != 0 && req.GetUserId() != 0:
+		return nil, status.Error(codes.InvalidArgument, "either user_id or conversation_id must be set, not both")
+	case req.GetConversationId() == 0 && (req.GetUserId() == 0 || req.GetUserId() == user):
+		return nil, status.Error(codes.InvalidArgument, "user_id of another user is required")
+	}
+	if req.GetText() == "" {
+		return nil, status.Error(codes.InvalidArgument, "text is required")
+	}
+	if utf8.RuneCountInString(req.GetText()) > maxTextLength {
+		return nil, status.Errorf(codes.InvalidArgument, "text must not exceed %d characters", maxTextLength)
+	}
+
+	if req.GetConversationId() != 0 {
+		c, err := s.conversation(ctx, user, req.GetConversationId())
+		if err != nil {
+			return nil, err
+		}
+		if c.ReadOnly {
+			return nil, status.Error(codes.FailedPrecondition, "conversation is read-only")
+		}
+		return toProto(s.messages.Post(req.GetConversationId(), user, req.GetText())), nil
+	}
+
+	// писать можно только друзьям; если relations не ответил, а в кеше
+	// связи нет, сообщение не доставляется
+	relation, err := s.policy.Check(ctx, user, req.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, "failed to check the relation with the user")
+	}
+	// ответ relations поправляет пометку переписки, если событие о
+	// связи было потеряно
+	s.messages.SetReadOnly(user, req.GetUserId(), !relation.Friends())
+	switch {
+	case relation.Blocked():
+		return nil, status.Error(codes.PermissionDenied, "messages to this user are not allowed")
+	case !relation.Friends():
+		return nil, status.Error(codes.PermissionDenied, "messages can only be sent to friends")
+	}
+
+	return toProto(s.messages.Add(user, req.GetUserId(), req.GetText())), nil
+}
+
+func (s *server) ListMessages(ctx context.Context, req *pb.ListMessagesRequest) (*pb.ListMessagesResponse, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if (req.GetUserId() == 0) == (req.GetConversationId() == 0) {
+		return nil, status.Error(codes.InvalidArgument, "either user_id or conversation_id is required")
+	}
+
+	size := int(req.GetPageSize())
+	switch {
+	case size == 0:
+		size = defaultPageSize
+	case size > maxPageSize:
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must not exceed %d", maxPageSize)
+	}
+
+	var list []messages.Message
+	if req.GetConversationId() != 0 {
+		if _, err := s.conversation(ctx, user, req.GetConversationId()); err != nil {
+			return nil, err
+		}
+		list = s.messages.ListConversation(req.GetConversationId(), req.GetBeforeId(), size)
+	} else {
+		list = s.messages.List(user, req.GetUserId(), req.GetBeforeId(), size)
+	}
+	resp := &pb.ListMessagesResponse{Messages: make([]*pb.Message, 0, len(list))}
+	for _, m := range list {
+		resp.Messages = append(resp.Messages, toProto(m))
+	}
+	return resp, nil
+}
+
+// caller возвращает id пользователя, от имени которого пришел вызов.
+func caller(ctx context.Context) (uint64, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(userIDHeader)
+	if len(values) == 0 {
+		return 0, status.Error(codes.Unauthenticated, "user id is required")
+	}
+
+	id, err := strconv.ParseUint(values[0], 10, 64)
+	if err != nil || id == 0 {
+		return 0, status.Error(codes.Unauthenticated, "invalid user id")
+	}
+	return id, nil
+}
+
+func toProto(m messages.Message) *pb.Message {
+	return &pb.Message{
+		Id:             m.ID,
+		SenderId:       m.From,
+		RecipientId:    m.To,
+		Text:           m.Text,
+		SentAt:         timestamppb.New(m.SentAt),
+		ConversationId: m.Conversation,
+	}
+}
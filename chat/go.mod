@@ -2,17 +2,34 @@ module github.com/zura-t/go_messenger/chat
 
 go 1.23.0
 
-require github.com/labstack/echo/v4 v4.13.3
+require (
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.26.3
+	github.com/zura-t/go_messenger/platform v0.0.0
+	github.com/zura-t/go_messenger/relations v0.0.0
+	google.golang.org/genproto/googleapis/api v0.0.0-20250303144028-a0af3efb3deb
+	google.golang.org/grpc v1.72.1
+	google.golang.org/protobuf v1.36.6
+)
 
 require (
+	github.com/BurntSushi/toml v1.5.0 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/labstack/echo/v4 v4.13.3 // indirect
 	github.com/labstack/gommon v0.4.2 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasttemplate v1.2.2 // indirect
-	golang.org/x/crypto v0.31.0 // indirect
-	golang.org/x/net v0.33.0 // indirect
-	golang.org/x/sys v0.28.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
-	golang.org/x/time v0.8.0 // indirect
+	golang.org/x/crypto v0.33.0 // indirect
+	golang.org/x/net v0.35.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250303144028-a0af3efb3deb // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/zura-t/go_messenger/accounts => ../accounts
+
+replace github.com/zura-t/go_messenger/platform => ../platform
+
+replace github.com/zura-t/go_messenger/relations => ../relations
@@ -0,0 +1,265 @@
+// Package messages хранит личные сообщения по переписке каждой пары
+// пользователей и групповые переписки.
+package messages
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	ID   uint64
+	From uint64
+	// To - получатель личного сообщения; 0 в групповой переписке.
+	To uint64
+	// Conversation - групповая переписка; 0 у личного сообщения.
+	Conversation uint64
+	Text         string
+	SentAt       time.Time
+}
+
+// Peer возвращает собеседника user в переписке, где лежит личное сообщение.
+func (m Message) Peer(user uint64) uint64 {
+	if m.From == user {
+		return m.To
+	}
+	return m.From
+}
+
+// Conversation - групповая переписка. Участники фиксируются при создании
+// и не зависят от того, что потом станет с группой друзей.
+type Conversation struct {
+	ID        uint64
+	Title     string
+	CreatedBy uint64
+	// Members - участники по возрастанию id, включая CreatedBy.
+	Members   []uint64
+	CreatedAt time.Time
+	// ReadOnly - двое участников заблокировали один другого, и писать
+	// в переписку больше нельзя.
+	ReadOnly bool
+}
+
+// Member сообщает, участвует ли user в переписке.
+func (c Conversation) Member(user uint64) bool {
+	_, ok := slices.BinarySearch(c.Members, user)
+	return ok
+}
+
+type pair struct{ low, high uint64 }
+
+func key(a, b uint64) pair {
+	if a > b {
+		a, b = b, a
+	}
+	return pair{a, b}
+}
+
+// Store хранит сообщения в памяти процесса. Id сообщений растут,
+// поэтому в каждой переписке сообщения упорядочены по id.
+type Store struct {
+	mx      sync.RWMutex
+	lastID  uint64
+	threads map[pair][]Message
+	// peers - собеседники каждого пользователя
+	peers map[uint64]map[uint64]struct{}
+	// readOnly - личные переписки пар, которые перестали быть друзьями
+	readOnly map[pair]struct{}
+
+	lastConversationID uint64
+	conversations      map[uint64]*Conversation
+	// byMember - id переписок пользователя в порядке создания
+	byMember map[uint64][]uint64
+	// groupThreads - сообщения групповых переписок по id переписки
+	groupThreads map[uint64][]Message
+}
+
+func NewStore() *Store {
+	return &Store{
+		threads:       make(map[pair][]Message),
+		peers:         make(map[uint64]map[uint64]struct{}),
+		readOnly:      make(map[pair]struct{}),
+		conversations: make(map[uint64]*Conversation),
+		byMember:      make(map[uint64][]uint64),
+		groupThreads:  make(map[uint64][]Message),
+	}
+}
+
+func (s *Store) Add(from, to uint64, text string) Message {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.lastID++
+	m := Message{ID: s.lastID, From: from, To: to, Text: text, SentAt: time.Now()}
+	k := key(from, to)
+	s.threads[k] = append(s.threads[k], m)
+	s.addPeer(from, to)
+	s.addPeer(to, from)
+	return m
+}
+
+func (s *Store) addPeer(user, peer uint64) {
+	if s.peers[user] == nil {
+		s.peers[user] = make(map[uint64]struct{})
+	}
+	s.peers[user][peer] = struct{}{}
+}
+
+// List возвращает до limit сообщений переписки a и b старше beforeID
+// (0 - с последнего), новые первыми.
+func (s *Store) List(a, b, beforeID uint64, limit int) []Message {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	return page(s.threads[key(a, b)], beforeID, limit)
+}
+
+// Last возвращает последнее сообщение каждой личной переписки user,
+// новые первыми.
+func (s *Store) Last(user uint64) []Message {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	out := make([]Message, 0, len(s.peers[user]))
+	for peer := range s.peers[user] {
+		thread := s.threads[key(user, peer)]
+		out = append(out, thread[len(thread)-1])
+	}
+	slices.SortFunc(out, func(a, b Message) int { return cmp.Compare(b.ID, a.ID) })
+	return out
+}
+
+// SetReadOnly закрывает личную переписку a и b для новых сообщений или
+// снова открывает ее. Пары, которые еще не переписывались, не запоминаются.
+func (s *Store) SetReadOnly(a, b uint64, readOnly bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	k := key(a, b)
+	switch {
+	case !readOnly:
+		delete(s.readOnly, k)
+	case len(s.threads[k]) != 0:
+		s.readOnly[k] = struct{}{}
+	}
+}
+
+// ReadOnly сообщает, закрыта ли личная переписка a и b.
+func (s *Store) ReadOnly(a, b uint64) bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	_, ok := s.readOnly[key(a, b)]
+	return ok
+}
+
+// StartConversation создает групповую переписку creator с members.
+func (s *Store) StartConversation(creator uint64, title string, members []uint64) Conversation {
+	members = append(slices.Clone(members), creator)
+	slices.Sort(members)
+	members = slices.Compact(members)
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.lastConversationID++
+	c := &Conversation{
+		ID:        s.lastConversationID,
+		Title:     title,
+		CreatedBy: creator,
+		Members:   members,
+		CreatedAt: time.Now(),
+	}
+	s.conversations[c.ID] = c
+	for _, user := range members {
+		s.byMember[user] = append(s.byMember[user], c.ID)
+	}
+	return *c
+}
+
+func (s *Store) Conversation(id uint64) (Conversation, bool) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	c, ok := s.conversations[id]
+	if !ok {
+		return Conversation{}, false
+	}
+	return *c, true
+}
+
+// Conversations возвращает групповые переписки user, новые первыми.
+func (s *Store) Conversations(user uint64) []Conversation {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	ids := s.byMember[user]
+	out := make([]Conversation, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		out = append(out, *s.conversations[ids[i]])
+	}
+	return out
+}
+
+// LockConversations закрывает групповые переписки, в которых участвуют
+// и a, и b.
+func (s *Store) LockConversations(a, b uint64) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for _, id := range s.byMember[a] {
+		if c := s.conversations[id]; c.Member(b) {
+			c.ReadOnly = true
+		}
+	}
+}
+
+// Post добавляет сообщение from в групповую переписку. Что from в ней
+// участвует, проверяет вызывающий.
+func (s *Store) Post(conversation, from uint64, text string) Message {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.lastID++
+	m := Message{ID: s.lastID, From: from, Conversation: conversation, Text: text, SentAt: time.Now()}
+	s.groupThreads[conversation] = append(s.groupThreads[conversation], m)
+	return m
+}
+
+// ListConversation возвращает сообщения групповой переписки, как List.
+func (s *Store) ListConversation(conversation, beforeID uint64, limit int) []Message {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	return page(s.groupThreads[conversation], beforeID, limit)
+}
+
+// LastPost возвращает последнее сообщение групповой переписки; false,
+// если в нее еще не писали.
+func (s *Store) LastPost(conversation uint64) (Message, bool) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	thread := s.groupThreads[conversation]
+	if len(thread) == 0 {
+		return Message{}, false
+	}
+	return thread[len(thread)-1], true
+}
+
+// page возвращает до limit сообщений thread старше beforeID, новые первыми.
+func page(thread []Message, beforeID uint64, limit int) []Message {
+	end := len(thread)
+	if beforeID != 0 {
+		end = sort.Search(len(thread), func(i int) bool { return thread[i].ID >= beforeID })
+	}
+
+	out := make([]Message, 0, min(limit, end))
+	for i := end - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, thread[i])
+	}
+	return out
+}
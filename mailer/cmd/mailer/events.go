@@ -0,0 +1,21 @@
+package main
+
+import (
+	"github.com/zura-t/go_messenger/mailer/internal/notify"
+	rpb "github.com/zura-t/go_messenger/relations/pkg/relations"
+)
+
+// notification - о каком событии relations пишется письмо: получатель -
+// target_id, ему пришла заявка или его заявку приняли.
+func notification(e *rpb.RelationEvent) (notify.Notification, bool) {
+	n := notify.Notification{Recipient: e.GetTargetId(), Actor: e.GetActorId()}
+	switch e.GetType() {
+	case rpb.RelationEventType_RELATION_EVENT_TYPE_REQUEST_SENT:
+		n.Kind = notify.FriendRequest
+	case rpb.RelationEventType_RELATION_EVENT_TYPE_REQUEST_ACCEPTED:
+		n.Kind = notify.FriendAccepted
+	default:
+		return notify.Notification{}, false
+	}
+	return n, true
+}
@@ -1,15 +1,98 @@
 package main
 
 import (
+	"log"
+	"log/slog"
 	"net/http"
+	netmail "net/mail"
 	"os"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	apb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+	"github.com/zura-t/go_messenger/mailer/internal/mail"
+	"github.com/zura-t/go_messenger/mailer/internal/notify"
+	"github.com/zura-t/go_messenger/platform/config"
+	"github.com/zura-t/go_messenger/platform/grpcpool"
+	"github.com/zura-t/go_messenger/platform/health"
+	"github.com/zura-t/go_messenger/platform/lifecycle"
+	"github.com/zura-t/go_messenger/platform/resilience"
+	"github.com/zura-t/go_messenger/platform/servicetoken"
+	rpb "github.com/zura-t/go_messenger/relations/pkg/relations"
+	"github.com/zura-t/go_messenger/relations/pkg/watch"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
+	defaults := config.Default()
+	defaults.HTTP.Addr = ":8082"
+	defaults.Endpoints.Accounts = "localhost:8081"
+	defaults.Endpoints.Relations = "localhost:8083"
+
+	cfg, err := config.Load(defaults, os.Args[1:])
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if err := cfg.Require("http.addr", "endpoints.accounts", "endpoints.relations", "tokens.service_token"); err != nil {
+		log.Fatal(err)
+	}
+	slog.SetLogLoggerLevel(cfg.Log.SlogLevel())
+	log.Printf("config:\n%s", cfg)
+
+	from, err := netmail.ParseAddress(cfg.Mail.From)
+	if err != nil {
+		log.Fatalf("invalid mail.from: %v", err)
+	}
+	var sender mail.Sender = mail.Log{}
+	if cfg.Mail.SMTPAddr != "" {
+		sender = mail.SMTP{
+			Addr:     cfg.Mail.SMTPAddr,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword,
+			From:     *from,
+		}
+	} else {
+		log.Printf("mail.smtp_addr is not set, emails are only logged")
+	}
+
+	clientCreds := insecure.NewCredentials()
+	if cfg.TLS.CAFile != "" {
+		clientCreds, err = credentials.NewClientTLSFromFile(cfg.TLS.CAFile, "")
+		if err != nil {
+			log.Fatalf("failed to load CA certificate: %v", err)
+		}
+	}
+
+	// accounts отдает адреса, имена и настройки уведомлений получателей
+	accountsPolicy := resilience.New("accounts", cfg.Backends.Accounts)
+	accountsConn, err := grpcpool.New(cfg.Endpoints.Accounts, cfg.Endpoints.PoolSize,
+		append(accountsPolicy.DialOptions(), grpc.WithTransportCredentials(clientCreds), servicetoken.Attach(cfg.Tokens.ServiceToken))...)
+	if err != nil {
+		log.Fatalf("failed to create accounts client: %v", err)
+	}
+	defer accountsConn.Close()
+
+	// relations присылает события, о которых пишутся письма
+	relationsPolicy := resilience.New("relations", cfg.Backends.Relations)
+	relationsConn, err := grpcpool.New(cfg.Endpoints.Relations, cfg.Endpoints.PoolSize,
+		append(relationsPolicy.DialOptions(), grpc.WithTransportCredentials(clientCreds), servicetoken.Attach(cfg.Tokens.ServiceToken))...)
+	if err != nil {
+		log.Fatalf("failed to create relations client: %v", err)
+	}
+	defer relationsConn.Close()
+
+	notifier := notify.New(apb.NewAccountsServiceClient(accountsConn), sender, cfg.Mail.AppURL, cfg.Mail.DigestWindow)
+
+	checker := health.NewChecker(2 * time.Second)
+	checker.Add("accounts", health.GRPC(accountsConn))
+	checker.Add("accounts.breaker", accountsPolicy.Check)
+	checker.Add("relations", health.GRPC(relationsConn))
+	checker.Add("relations.breaker", relationsPolicy.Check)
+
 	e := echo.New()
 
 	e.Use(middleware.Logger())
@@ -21,20 +104,38 @@ func main() {
 	})
 
 	// for readiness probe
-	e.GET("/ready", func(c echo.Context) error {
-		return c.JSON(http.StatusOK, struct{ Status string }{Status: "OK"})
-	})
+	e.GET("/ready", checker.Handler)
 
 	e.GET("/hello", func(c echo.Context) error {
 		return c.HTML(http.StatusOK, "Hello, Docker!")
 	})
 
-	httpPort := os.Getenv("PORT")
-	if httpPort == "" {
-		httpPort = "8082"
-	}
+	ctx, stop := lifecycle.SignalContext()
+	defer stop()
 
-	time.Sleep(5 * time.Second)
+	// события, пропущенные при переподключении к relations, теряются:
+	// письма о них не важны настолько, чтобы их восстанавливать
+	watcher := make(chan struct{})
+	go func() {
+		defer close(watcher)
+		watch.Run(ctx, rpb.NewRelationsServiceClient(relationsConn), &rpb.WatchRelationsRequest{}, watch.Handler{
+			Event: func(e *rpb.RelationEvent) {
+				n, ok := notification(e)
+				if !ok {
+					return
+				}
+				if err := notifier.Add(n); err != nil {
+					slog.Warn("notification dropped", "type", n.Kind, "recipient", n.Recipient, "error", err)
+				}
+			},
+		})
+	}()
 
-	e.Logger.Fatal(e.Start(":" + httpPort))
+	err = lifecycle.ServeEcho(ctx, e, cfg, checker.Drain)
+	// новых уведомлений больше нет, а накопленные отправляются, не дожидаясь digest_window
+	<-watcher
+	notifier.Close()
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
 }
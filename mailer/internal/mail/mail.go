@@ -0,0 +1,72 @@
+// Package mail отправляет письма через SMTP или, без SMTP сервера,
+// только записывает их в лог.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Email - текстовое письмо одному получателю.
+type Email struct {
+	To      mail.Address
+	Subject string
+	Body    string
+}
+
+// Sender отправляет письма.
+type Sender interface {
+	Send(ctx context.Context, e Email) error
+}
+
+// Log записывает письма в лог вместо отправки: для разработки и тестовых стендов.
+type Log struct{}
+
+func (Log) Send(ctx context.Context, e Email) error {
+	slog.InfoContext(ctx, "email", "to", e.To.String(), "subject", e.Subject, "body", e.Body)
+	return nil
+}
+
+// SMTP отправляет письма через SMTP сервер; с Username - с PLAIN авторизацией.
+type SMTP struct {
+	Addr     string
+	Username string
+	Password string
+	From     mail.Address
+}
+
+// Send не прерывается отменой ctx: net/smtp ее не поддерживает.
+func (s SMTP) Send(_ context.Context, e Email) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return fmt.Errorf("smtp address: %w", err)
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	if err := smtp.SendMail(s.Addr, auth, s.From.Address, []string{e.To.Address}, s.message(e)); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return nil
+}
+
+func (s SMTP) message(e Email) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From.String())
+	fmt.Fprintf(&b, "To: %s\r\n", e.To.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", e.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(e.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,228 @@
+// Package notify собирает уведомления о заявках в друзья и отправляет их
+// письмами по шаблонам, учитывая настройки получателя в accounts.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	netmail "net/mail"
+	"slices"
+	"sync"
+	"text/template"
+	"time"
+
+	apb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+	"github.com/zura-t/go_messenger/mailer/internal/mail"
+)
+
+type Kind string
+
+const (
+	// FriendRequest - Actor отправил получателю заявку в друзья.
+	FriendRequest Kind = "friend_request"
+	// FriendAccepted - Actor принял заявку получателя.
+	FriendAccepted Kind = "friend_accepted"
+)
+
+type Notification struct {
+	Kind      Kind
+	Recipient uint64
+	Actor     uint64
+}
+
+// ErrClosed - Notifier закрыт и уведомления не принимает.
+var ErrClosed = errors.New("notifier is closed")
+
+const (
+	// maxContacts - лимит AccountsService.GetContacts на один вызов.
+	maxContacts = 100
+	// maxDigestItems - сколько уведомлений перечисляется в digest, об остальных - только число.
+	maxDigestItems = 20
+	deliverTimeout = 30 * time.Second
+)
+
+//go:embed templates/*.tmpl
+var files embed.FS
+
+var templates = map[string]*template.Template{
+	string(FriendRequest):  template.Must(template.ParseFS(files, "templates/friend_request.tmpl")),
+	string(FriendAccepted): template.Must(template.ParseFS(files, "templates/friend_accepted.tmpl")),
+	"digest":               template.Must(template.ParseFS(files, "templates/digest.tmpl")),
+}
+
+// Notifier копит уведомления каждого получателя window после первого
+// из них и отправляет все вместе: одно уведомление - своим шаблоном,
+// несколько - одним digest, если получатель его не отключил.
+type Notifier struct {
+	accounts apb.AccountsServiceClient
+	sender   mail.Sender
+	appURL   string
+	window   time.Duration
+
+	mx      sync.Mutex
+	pending map[uint64]*batch
+	closed  bool
+	// wg ждет отправку пачек, таймер которых уже сработал
+	wg sync.WaitGroup
+}
+
+type batch struct {
+	items []Notification
+	timer *time.Timer
+}
+
+func New(accounts apb.AccountsServiceClient, sender mail.Sender, appURL string, window time.Duration) *Notifier {
+	return &Notifier{
+		accounts: accounts,
+		sender:   sender,
+		appURL:   appURL,
+		window:   window,
+		pending:  make(map[uint64]*batch),
+	}
+}
+
+// Add откладывает уведомление до конца окна получателя. После Close
+// уведомление отбрасывается с ErrClosed.
+func (n *Notifier) Add(x Notification) error {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	if n.closed {
+		return ErrClosed
+	}
+	b, ok := n.pending[x.Recipient]
+	if !ok {
+		b = &batch{}
+		n.wg.Add(1)
+		b.timer = time.AfterFunc(n.window, func() {
+			defer n.wg.Done()
+			n.flush(x.Recipient)
+		})
+		n.pending[x.Recipient] = b
+	}
+	b.items = append(b.items, x)
+	return nil
+}
+
+// Close сразу отправляет все накопленные уведомления и ждет отправки.
+// Уведомления, добавленные после Close, отбрасываются.
+func (n *Notifier) Close() {
+	n.mx.Lock()
+	n.closed = true
+	var recipients []uint64
+	for recipient, b := range n.pending {
+		if b.timer.Stop() {
+			n.wg.Done()
+			recipients = append(recipients, recipient)
+		}
+	}
+	n.mx.Unlock()
+
+	for _, recipient := range recipients {
+		n.flush(recipient)
+	}
+	n.wg.Wait()
+}
+
+func (n *Notifier) flush(recipient uint64) {
+	n.mx.Lock()
+	b := n.pending[recipient]
+	delete(n.pending, recipient)
+	n.mx.Unlock()
+	if b == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deliverTimeout)
+	defer cancel()
+	if err := n.deliver(ctx, recipient, b.items); err != nil {
+		slog.Error("failed to send notifications", "recipient", recipient, "count", len(b.items), "error", err)
+	}
+}
+
+// item - уведомление, как его видит шаблон.
+type item struct {
+	Kind  Kind
+	Actor string
+}
+
+func (n *Notifier) deliver(ctx context.Context, recipient uint64, items []Notification) error {
+	ids := []uint64{recipient}
+	for _, x := range items {
+		ids = append(ids, x.Actor)
+	}
+	slices.Sort(ids)
+	contacts, err := n.contacts(ctx, slices.Compact(ids))
+	if err != nil {
+		return err
+	}
+
+	to, ok := contacts[recipient]
+	if !ok {
+		// получатель удалил профиль
+		return nil
+	}
+	settings := to.GetNotifications()
+	var wanted []item
+	for _, x := range items {
+		actor, ok := contacts[x.Actor]
+		if !ok {
+			continue
+		}
+		if x.Kind == FriendRequest && !settings.GetFriendRequests() ||
+			x.Kind == FriendAccepted && !settings.GetFriendAccepted() {
+			continue
+		}
+		wanted = append(wanted, item{Kind: x.Kind, Actor: actor.GetName()})
+	}
+
+	address := netmail.Address{Name: to.GetName(), Address: to.GetEmail()}
+	if len(wanted) > 1 && settings.GetDigest() {
+		data := map[string]any{
+			"Recipient": to.GetName(),
+			"Items":     wanted[:min(len(wanted), maxDigestItems)],
+			"More":      max(len(wanted)-maxDigestItems, 0),
+			"Total":     len(wanted),
+			"AppURL":    n.appURL,
+		}
+		return n.send(ctx, address, "digest", data)
+	}
+
+	var errs []error
+	for _, it := range wanted {
+		data := map[string]any{"Recipient": to.GetName(), "Actor": it.Actor, "AppURL": n.appURL}
+		errs = append(errs, n.send(ctx, address, string(it.Kind), data))
+	}
+	return errors.Join(errs...)
+}
+
+// contacts возвращает контакты пользователей ids по их id.
+func (n *Notifier) contacts(ctx context.Context, ids []uint64) (map[uint64]*apb.Contact, error) {
+	out := make(map[uint64]*apb.Contact, len(ids))
+	for chunk := range slices.Chunk(ids, maxContacts) {
+		resp, err := n.accounts.GetContacts(ctx, &apb.GetContactsRequest{Ids: chunk})
+		if err != nil {
+			return nil, fmt.Errorf("get contacts: %w", err)
+		}
+		for _, c := range resp.GetContacts() {
+			out[c.GetId()] = c
+		}
+	}
+	return out, nil
+}
+
+func (n *Notifier) send(ctx context.Context, to netmail.Address, name string, data any) error {
+	var subject, body bytes.Buffer
+	t := templates[name]
+	if err := t.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return fmt.Errorf("render %s: %w", name, err)
+	}
+	if err := t.ExecuteTemplate(&body, "body", data); err != nil {
+		return fmt.Errorf("render %s: %w", name, err)
+	}
+	return n.sender.Send(ctx, mail.Email{To: to, Subject: subject.String(), Body: body.String()})
+}
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	apb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+	"github.com/zura-t/go_messenger/mailer/internal/mail"
+
+	"google.golang.org/grpc"
+)
+
+type contacts struct {
+	apb.AccountsServiceClient
+	byID map[uint64]*apb.Contact
+}
+
+func (c contacts) GetContacts(_ context.Context, req *apb.GetContactsRequest, _ ...grpc.CallOption) (*apb.GetContactsResponse, error) {
+	resp := &apb.GetContactsResponse{}
+	for _, id := range req.GetIds() {
+		if contact, ok := c.byID[id]; ok {
+			resp.Contacts = append(resp.Contacts, contact)
+		}
+	}
+	return resp, nil
+}
+
+type outbox struct {
+	mx     sync.Mutex
+	emails []mail.Email
+}
+
+func (o *outbox) Send(_ context.Context, e mail.Email) error {
+	o.mx.Lock()
+	defer o.mx.Unlock()
+	o.emails = append(o.emails, e)
+	return nil
+}
+
+func contact(id uint64, name string, settings *apb.NotificationSettings) *apb.Contact {
+	return &apb.Contact{Id: id, Name: name, Email: name + "@example.com", Notifications: settings}
+}
+
+func newTestNotifier(window time.Duration) (*Notifier, *outbox) {
+	all := &apb.NotificationSettings{FriendRequests: true, FriendAccepted: true, Digest: true}
+	accounts := contacts{byID: map[uint64]*apb.Contact{
+		1: contact(1, "alice", all),
+		2: contact(2, "bob", all),
+		3: contact(3, "carol", all),
+		4: contact(4, "dave", &apb.NotificationSettings{FriendRequests: true, FriendAccepted: true}),
+		5: contact(5, "erin", &apb.NotificationSettings{FriendAccepted: true, Digest: true}),
+	}}
+	out := &outbox{}
+	return New(accounts, out, "http://app", window), out
+}
+
+func TestNotifierBatches(t *testing.T) {
+	n, out := newTestNotifier(time.Hour)
+	for _, x := range []Notification{
+		{Kind: FriendRequest, Recipient: 1, Actor: 2},
+		{Kind: FriendRequest, Recipient: 1, Actor: 3},
+		{Kind: FriendRequest, Recipient: 4, Actor: 2},
+		{Kind: FriendAccepted, Recipient: 4, Actor: 3},
+		{Kind: FriendRequest, Recipient: 5, Actor: 2},
+		{Kind: FriendRequest, Recipient: 2, Actor: 99},
+	} {
+		if err := n.Add(x); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n.Close()
+
+	subjects := make(map[string][]string)
+	for _, e := range out.emails {
+		subjects[e.To.Address] = append(subjects[e.To.Address], e.Subject)
+	}
+	if got := subjects["alice@example.com"]; len(got) != 1 || !strings.HasPrefix(got[0], "2 new notifications") {
+		t.Errorf("alice: got %q, want one digest of 2", got)
+	}
+	if got := subjects["dave@example.com"]; len(got) != 2 {
+		t.Errorf("dave has digests off: got %q, want 2 separate emails", got)
+	}
+	if got := subjects["erin@example.com"]; len(got) != 0 {
+		t.Errorf("erin turned friend requests off: got %q", got)
+	}
+	if got := subjects["bob@example.com"]; len(got) != 0 {
+		t.Errorf("bob's only notification is from an unknown user: got %q", got)
+	}
+}
+
+func TestNotifierSendsAfterWindow(t *testing.T) {
+	n, out := newTestNotifier(10 * time.Millisecond)
+	if err := n.Add(Notification{Kind: FriendAccepted, Recipient: 1, Actor: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		out.mx.Lock()
+		sent := len(out.emails)
+		out.mx.Unlock()
+		if sent == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("email was not sent after the window")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := out.emails[0].Subject; got != "bob accepted your friend request" {
+		t.Errorf("subject = %q", got)
+	}
+	n.Close()
+}
+
+func TestNotifierRejectsAfterClose(t *testing.T) {
+	n, out := newTestNotifier(time.Millisecond)
+	n.Close()
+
+	err := n.Add(Notification{Kind: FriendRequest, Recipient: 1, Actor: 2})
+	if !errors.Is(err, ErrClosed) {
+		t.Fatalf("Add after Close = %v, want ErrClosed", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if len(out.emails) != 0 {
+		t.Errorf("email sent after Close: %v", out.emails)
+	}
+}
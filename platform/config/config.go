@@ -0,0 +1,357 @@
+// Package config загружает настройки сервисов из значений по умолчанию,
+// файла YAML/TOML, переменных окружения и флагов - в этом порядке,
+// каждый следующий источник перекрывает предыдущий.
+//
+// Имя переменной окружения и флага выводится из пути до поля:
+// поле addr в секции http читается из HTTP_ADDR и флага -http-addr.
+// Тег env задает имя переменной явно, тег secret скрывает значение при печати.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	HTTP       Server     `yaml:"http"`
+	GRPC       Server     `yaml:"grpc"`
+	Metrics    Server     `yaml:"metrics"`
+	Database   Database   `yaml:"database"`
+	Tokens     Tokens     `yaml:"tokens"`
+	IDs        IDs        `yaml:"ids"`
+	Endpoints  Endpoints  `yaml:"endpoints"`
+	Backends   Backends   `yaml:"backends"`
+	RateLimits RateLimits `yaml:"rate_limits"`
+	Browser    Browser    `yaml:"browser"`
+	Relations  Relations  `yaml:"relations"`
+	Mail       Mail       `yaml:"mail"`
+	TLS        TLS        `yaml:"tls"`
+	Log        Log        `yaml:"log"`
+	Shutdown   Shutdown   `yaml:"shutdown"`
+}
+
+type Server struct {
+	Addr string `yaml:"addr" usage:"listen address"`
+}
+
+type Database struct {
+	DSN string `yaml:"dsn" secret:"true" usage:"database connection string"`
+}
+
+type Tokens struct {
+	AccessTTL    time.Duration `yaml:"access_ttl" usage:"access token lifetime"`
+	RefreshTTL   time.Duration `yaml:"refresh_ttl" usage:"refresh token lifetime"`
+	SigningKey   string        `yaml:"signing_key" env:"TOKEN_SIGNING_KEY" secret:"true" usage:"base64 Ed25519 seed used to sign access tokens"`
+	SyncInterval time.Duration `yaml:"sync_interval" usage:"how often signing keys and revoked sessions are synced from accounts"`
+	ServiceToken string        `yaml:"service_token" env:"SERVICE_TOKEN" secret:"true" usage:"shared token services present to call internal RPCs"`
+}
+
+type IDs struct {
+	Node uint16 `yaml:"node" env:"NODE_ID" usage:"unique node number of this replica for id generation, 1-1023"`
+	Key  string `yaml:"key" env:"USER_ID_KEY" secret:"true" usage:"key used to make public user ids opaque"`
+	// Dev разрешает запуск без node и key - только для одной реплики
+	// на машине разработчика.
+	Dev bool `yaml:"dev" usage:"allow a built-in ids.key and a node number derived from hostname; single replica only"`
+}
+
+// Endpoints - адреса других сервисов.
+type Endpoints struct {
+	Accounts  string `yaml:"accounts" env:"ACCOUNTS_ADDR" usage:"accounts gRPC address"`
+	Relations string `yaml:"relations" env:"RELATIONS_ADDR" usage:"relations address"`
+	Chat      string `yaml:"chat" env:"CHAT_ADDR" usage:"chat address"`
+	Mailer    string `yaml:"mailer" env:"MAILER_ADDR" usage:"mailer address"`
+	Redis     string `yaml:"redis" env:"REDIS_ADDR" usage:"Redis address shared by gateway replicas; state is kept in memory if empty"`
+	PoolSize  int    `yaml:"pool_size" usage:"number of gRPC connections kept to each service"`
+}
+
+// Backends - как вызываются другие сервисы: у каждого свои дедлайны,
+// повторы и circuit breaker.
+type Backends struct {
+	Accounts  Backend `yaml:"accounts"`
+	Relations Backend `yaml:"relations"`
+	Chat      Backend `yaml:"chat"`
+}
+
+// Backend - политика вызовов одного сервиса. Повторяются только вызовы,
+// помеченные в proto как идемпотентные.
+type Backend struct {
+	Timeout         time.Duration `yaml:"timeout" usage:"deadline of one call attempt; 0 keeps the caller's deadline"`
+	Retries         int           `yaml:"retries" usage:"how many times a failed idempotent call is retried"`
+	BackoffBase     time.Duration `yaml:"backoff_base" usage:"delay before the first retry, doubled for every next one"`
+	BackoffMax      time.Duration `yaml:"backoff_max" usage:"upper bound of the delay between retries"`
+	BreakerFailures int           `yaml:"breaker_failures" usage:"consecutive failures that open the circuit breaker; 0 disables it"`
+	BreakerCooldown time.Duration `yaml:"breaker_cooldown" usage:"how long the open circuit breaker rejects calls before a probe call"`
+}
+
+// RateLimits - лимиты групп маршрутов api-gateway в виде "5/1m":
+// не больше 5 запросов в минуту от пользователя или IP. Пустой лимит отключает группу.
+type RateLimits struct {
+	Login    string `yaml:"login" usage:"login rate limit, e.g. 5/1m"`
+	Register string `yaml:"register" usage:"registration rate limit"`
+	ChatSend string `yaml:"chat_send" usage:"chat message sending rate limit"`
+	Search   string `yaml:"search" usage:"user search rate limit"`
+}
+
+// Browser - настройки api-gateway для браузерных клиентов.
+type Browser struct {
+	AllowedOrigins        string        `yaml:"allowed_origins" usage:"comma-separated origins allowed to call the API from a browser; CORS is off if empty"`
+	AllowCredentials      bool          `yaml:"allow_credentials" usage:"let cross-origin requests carry cookies"`
+	PreflightMaxAge       time.Duration `yaml:"preflight_max_age" usage:"how long browsers may cache CORS preflight responses"`
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age" usage:"Strict-Transport-Security max-age for HTTPS requests; 0 disables the header"`
+	ContentSecurityPolicy string        `yaml:"content_security_policy" usage:"Content-Security-Policy header of API responses"`
+	Cookies               bool          `yaml:"cookies" usage:"let browser clients keep tokens in cookies with CSRF protection instead of the Authorization header"`
+}
+
+// Relations - правила сервиса relations.
+type Relations struct {
+	SuggestLimit int `yaml:"suggest_limit" usage:"max friend suggestions returned by one call"`
+	SuggestScan  int `yaml:"suggest_scan" usage:"how many most recent friends of the user, and of each of them, are scanned for suggestions"`
+	MutualScan   int `yaml:"mutual_scan" usage:"how many most recent friends of each user are compared for mutual friends"`
+
+	DailyRequests     int           `yaml:"daily_requests" usage:"how many friend requests a user may send in 24 hours; 0 disables the quota"`
+	RejectionCooldown time.Duration `yaml:"rejection_cooldown" usage:"how long after a rejection or withdrawal the same request cannot be sent again"`
+	RequestTTL        time.Duration `yaml:"request_ttl" usage:"how long a friend request waits for an answer before it expires"`
+	SweepInterval     time.Duration `yaml:"sweep_interval" usage:"how often expired friend requests are swept"`
+
+	MaxGroups       int `yaml:"max_groups" usage:"how many friend groups a user may create"`
+	MaxGroupMembers int `yaml:"max_group_members" usage:"how many friends one group may hold"`
+
+	// CheckCacheTTL и CheckCacheSize - кеш CheckRelation в сервисах, которые проверяют связи (chat).
+	CheckCacheTTL  time.Duration `yaml:"check_cache_ttl" usage:"how long a cached relation check is trusted if no event drops it earlier"`
+	CheckCacheSize int           `yaml:"check_cache_size" usage:"how many relation checks are cached"`
+}
+
+// Mail - письма, которые отправляет mailer.
+type Mail struct {
+	From         string        `yaml:"from" usage:"sender of notification emails"`
+	AppURL       string        `yaml:"app_url" usage:"web app address used in links of emails"`
+	SMTPAddr     string        `yaml:"smtp_addr" env:"SMTP_ADDR" usage:"SMTP server host:port; emails are only logged if empty"`
+	SMTPUsername string        `yaml:"smtp_username" env:"SMTP_USERNAME" usage:"SMTP username, PLAIN auth is used if set"`
+	SMTPPassword string        `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true" usage:"SMTP password"`
+	DigestWindow time.Duration `yaml:"digest_window" usage:"how long notifications to one user are collected before sending; several are sent as one digest"`
+}
+
+type TLS struct {
+	CertFile string `yaml:"cert_file" usage:"server certificate file"`
+	KeyFile  string `yaml:"key_file" usage:"server private key file"`
+	CAFile   string `yaml:"ca_file" usage:"CA certificate used to verify other services"`
+}
+
+// Enabled сообщает, что сервер должен принимать соединения по TLS.
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+type Log struct {
+	Level string `yaml:"level" usage:"log level: debug, info, warn or error"`
+}
+
+type Shutdown struct {
+	Timeout time.Duration `yaml:"timeout" usage:"how long to wait for in-flight requests on shutdown"`
+}
+
+// SlogLevel возвращает уровень логирования для log/slog.
+func (l Log) SlogLevel() slog.Level {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(l.Level))
+	return level
+}
+
+var defaultBackend = Backend{
+	Timeout:         2 * time.Second,
+	Retries:         2,
+	BackoffBase:     50 * time.Millisecond,
+	BackoffMax:      time.Second,
+	BreakerFailures: 5,
+	BreakerCooldown: 10 * time.Second,
+}
+
+// Default возвращает настройки, общие для всех сервисов.
+func Default() Config {
+	return Config{
+		Tokens: Tokens{
+			AccessTTL:    15 * time.Minute,
+			RefreshTTL:   30 * 24 * time.Hour,
+			SyncInterval: 10 * time.Second,
+		},
+		Endpoints: Endpoints{PoolSize: 4},
+		Backends: Backends{
+			Accounts:  defaultBackend,
+			Relations: defaultBackend,
+			Chat:      defaultBackend,
+		},
+		RateLimits: RateLimits{
+			Login:    "10/1m",
+			Register: "5/1h",
+			ChatSend: "60/1m",
+			Search:   "30/1m",
+		},
+		Browser: Browser{
+			PreflightMaxAge:       10 * time.Minute,
+			HSTSMaxAge:            365 * 24 * time.Hour,
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		},
+		Relations: Relations{
+			SuggestLimit: 20,
+			SuggestScan:  200,
+			MutualScan:   5000,
+
+			DailyRequests:     50,
+			RejectionCooldown: 7 * 24 * time.Hour,
+			RequestTTL:        30 * 24 * time.Hour,
+			SweepInterval:     time.Minute,
+
+			MaxGroups:       50,
+			MaxGroupMembers: 500,
+
+			CheckCacheTTL:  time.Minute,
+			CheckCacheSize: 100000,
+		},
+		Mail: Mail{
+			From:         "Go Messenger <no-reply@go-messenger.local>",
+			AppURL:       "http://localhost:8080",
+			DigestWindow: time.Minute,
+		},
+		Log:      Log{Level: "info"},
+		Shutdown: Shutdown{Timeout: 15 * time.Second},
+	}
+}
+
+// Validate проверяет настройки, заданные хотя бы одним источником.
+func (c Config) Validate() error {
+	var errs []error
+
+	for _, addr := range []struct{ name, value string }{
+		{"http.addr", c.HTTP.Addr},
+		{"grpc.addr", c.GRPC.Addr},
+	} {
+		if addr.value == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addr.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", addr.name, err))
+		}
+	}
+
+	if c.Tokens.AccessTTL <= 0 {
+		errs = append(errs, errors.New("tokens.access_ttl must be positive"))
+	}
+	if c.Tokens.RefreshTTL < c.Tokens.AccessTTL {
+		errs = append(errs, errors.New("tokens.refresh_ttl must not be shorter than tokens.access_ttl"))
+	}
+	if c.Tokens.SyncInterval <= 0 {
+		errs = append(errs, errors.New("tokens.sync_interval must be positive"))
+	}
+
+	if c.Browser.AllowCredentials && slices.Contains(strings.Split(c.Browser.AllowedOrigins, ","), "*") {
+		errs = append(errs, errors.New("browser.allow_credentials cannot be used with the * origin"))
+	}
+
+	if c.Endpoints.PoolSize <= 0 {
+		errs = append(errs, errors.New("endpoints.pool_size must be positive"))
+	}
+
+	for _, b := range []struct {
+		name string
+		Backend
+	}{
+		{"backends.accounts", c.Backends.Accounts},
+		{"backends.relations", c.Backends.Relations},
+		{"backends.chat", c.Backends.Chat},
+	} {
+		if b.Timeout < 0 || b.Retries < 0 || b.BreakerFailures < 0 {
+			errs = append(errs, fmt.Errorf("%s: timeout, retries and breaker_failures must not be negative", b.name))
+		}
+		if b.Retries > 0 && (b.BackoffBase <= 0 || b.BackoffMax < b.BackoffBase) {
+			errs = append(errs, fmt.Errorf("%s: backoff_base must be positive and not greater than backoff_max", b.name))
+		}
+		if b.BreakerFailures > 0 && b.BreakerCooldown <= 0 {
+			errs = append(errs, fmt.Errorf("%s: breaker_cooldown must be positive", b.name))
+		}
+	}
+
+	if c.Relations.SuggestLimit <= 0 || c.Relations.SuggestScan <= 0 || c.Relations.MutualScan <= 0 {
+		errs = append(errs, errors.New("relations: suggest_limit, suggest_scan and mutual_scan must be positive"))
+	}
+	if c.Relations.DailyRequests < 0 || c.Relations.RejectionCooldown < 0 {
+		errs = append(errs, errors.New("relations: daily_requests and rejection_cooldown must not be negative"))
+	}
+	if c.Relations.RequestTTL <= 0 || c.Relations.SweepInterval <= 0 {
+		errs = append(errs, errors.New("relations: request_ttl and sweep_interval must be positive"))
+	}
+	if c.Relations.MaxGroups <= 0 || c.Relations.MaxGroupMembers <= 0 {
+		errs = append(errs, errors.New("relations: max_groups and max_group_members must be positive"))
+	}
+	if c.Relations.CheckCacheTTL <= 0 || c.Relations.CheckCacheSize <= 0 {
+		errs = append(errs, errors.New("relations: check_cache_ttl and check_cache_size must be positive"))
+	}
+	if c.Mail.DigestWindow <= 0 {
+		errs = append(errs, errors.New("mail: digest_window must be positive"))
+	}
+
+	if c.Shutdown.Timeout <= 0 {
+		errs = append(errs, errors.New("shutdown.timeout must be positive"))
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("tls.cert_file and tls.key_file must be set together"))
+	}
+	for _, file := range []struct{ name, path string }{
+		{"tls.cert_file", c.TLS.CertFile},
+		{"tls.key_file", c.TLS.KeyFile},
+		{"tls.ca_file", c.TLS.CAFile},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file.name, err))
+		}
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: unknown level %q", c.Log.Level))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Require проверяет, что поля с указанными путями (например "grpc.addr")
+// заданы. Так сервис объявляет настройки, без которых он не запустится.
+func (c Config) Require(paths ...string) error {
+	values := make(map[string]string)
+	for _, f := range fields(&c) {
+		values[f.path] = f.get()
+	}
+
+	var missing []string
+	for _, path := range paths {
+		if v, ok := values[path]; !ok || v == "" || v == "0" || v == "0s" {
+			missing = append(missing, path)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("required settings are not set: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// String печатает настройки в виде "путь = значение", скрывая секреты.
+func (c Config) String() string {
+	var b strings.Builder
+	for _, f := range fields(&c) {
+		value := f.get()
+		if f.secret && value != "" {
+			value = "[REDACTED]"
+		}
+		fmt.Fprintf(&b, "%s = %q\n", f.path, value)
+	}
+	return b.String()
+}
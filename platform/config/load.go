@@ -0,0 +1,234 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// field - одна настройка: лист структуры Config.
+type field struct {
+	path   string // http.addr
+	env    string // HTTP_ADDR
+	flag   string // http-addr
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+func (f field) get() string {
+	if d, ok := f.value.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return fmt.Sprint(f.value.Interface())
+}
+
+func (f field) set(raw string) error {
+	switch f.value.Interface().(type) {
+	case string:
+		f.value.SetString(raw)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.path, err)
+		}
+		f.value.SetInt(int64(d))
+	case int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.path, err)
+		}
+		f.value.SetInt(int64(n))
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.path, err)
+		}
+		f.value.SetBool(b)
+	case uint16:
+		n, err := strconv.ParseUint(raw, 10, 16)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.path, err)
+		}
+		f.value.SetUint(n)
+	default:
+		return fmt.Errorf("%s: unsupported type %s", f.path, f.value.Type())
+	}
+	return nil
+}
+
+// flagValue хранит значение флага строкой до разбора в field.set.
+// Флаг bool поля можно указать без значения: -ids-dev.
+type flagValue struct {
+	raw    string
+	isBool bool
+}
+
+func (v *flagValue) String() string       { return v.raw }
+func (v *flagValue) Set(raw string) error { v.raw = raw; return nil }
+func (v *flagValue) IsBoolFlag() bool     { return v.isBool }
+
+func fields(c *Config) []field {
+	var out []field
+	walk(reflect.ValueOf(c).Elem(), nil, &out)
+	return out
+}
+
+func walk(v reflect.Value, path []string, out *[]field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		p := append(append([]string(nil), path...), sf.Tag.Get("yaml"))
+
+		if sf.Type.Kind() == reflect.Struct {
+			walk(v.Field(i), p, out)
+			continue
+		}
+
+		env := sf.Tag.Get("env")
+		if env == "" {
+			env = strings.ToUpper(strings.Join(p, "_"))
+		}
+		*out = append(*out, field{
+			path:   strings.Join(p, "."),
+			env:    env,
+			flag:   strings.ReplaceAll(strings.Join(p, "-"), "_", "-"),
+			usage:  sf.Tag.Get("usage"),
+			secret: sf.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+}
+
+// Load накладывает на defaults файл настроек, переменные окружения и флаги
+// из args и проверяет результат. Путь к файлу задается флагом -config
+// или переменной CONFIG_FILE; формат определяется по расширению.
+// Флаг -print-config печатает итоговые настройки и завершает процесс.
+func Load(defaults Config, args []string) (Config, error) {
+	cfg := defaults
+	all := fields(&cfg)
+
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file (env CONFIG_FILE)")
+	printConfig := fs.Bool("print-config", false, "print the resulting config with secrets redacted and exit")
+	byFlag := make(map[string]field, len(all))
+	for _, f := range all {
+		byFlag[f.flag] = f
+		fs.Var(&flagValue{isBool: f.value.Kind() == reflect.Bool}, f.flag, fmt.Sprintf("%s (env %s)", f.usage, f.env))
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if *configFile != "" {
+		if err := loadFile(*configFile, all); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, f := range all {
+		if raw, ok := os.LookupEnv(f.env); ok {
+			if err := f.set(raw); err != nil {
+				return Config{}, fmt.Errorf("env %s: %w", f.env, err)
+			}
+		}
+	}
+
+	var err error
+	fs.Visit(func(fl *flag.Flag) {
+		if f, ok := byFlag[fl.Name]; ok && err == nil {
+			err = f.set(fl.Value.String())
+		}
+	})
+	if err != nil {
+		return Config{}, fmt.Errorf("flags: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+
+	if *printConfig {
+		fmt.Print(cfg)
+		os.Exit(0)
+	}
+
+	return cfg, nil
+}
+
+func loadFile(path string, all []field) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	doc := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &doc)
+	case ".toml":
+		err = toml.Unmarshal(raw, &doc)
+	default:
+		return fmt.Errorf("unsupported config file format %q", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file: %w", err)
+	}
+
+	if unknown := unknownKeys(doc, "", all); len(unknown) > 0 {
+		return fmt.Errorf("config file: unknown settings: %s", strings.Join(unknown, ", "))
+	}
+	for _, f := range all {
+		if v, ok := lookup(doc, strings.Split(f.path, ".")); ok {
+			if err := f.set(fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("config file: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// unknownKeys возвращает пути из doc, которым не соответствует ни одна
+// настройка, чтобы опечатка в файле не оставляла значение по умолчанию.
+func unknownKeys(doc map[string]any, prefix string, all []field) []string {
+	var unknown []string
+	for key, v := range doc {
+		path := prefix + key
+		nested, isSection := v.(map[string]any)
+		known := slices.ContainsFunc(all, func(f field) bool {
+			if isSection {
+				return strings.HasPrefix(f.path, path+".")
+			}
+			return f.path == path
+		})
+		switch {
+		case !known:
+			unknown = append(unknown, path)
+		case isSection:
+			unknown = append(unknown, unknownKeys(nested, path+".", all)...)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+func lookup(doc map[string]any, path []string) (any, bool) {
+	v, ok := doc[path[0]]
+	if !ok || len(path) == 1 {
+		return v, ok
+	}
+	nested, ok := v.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return lookup(nested, path[1:])
+}
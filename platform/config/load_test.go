@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile сохраняет файл настроек name во временный каталог теста.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPriority(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+grpc:
+  addr: ":9000"
+tokens:
+  access_ttl: 5m
+  refresh_ttl: 48h
+`)
+	t.Setenv("TOKENS_REFRESH_TTL", "72h")
+
+	cfg, err := Load(Default(), []string{"-config", path, "-grpc-addr", ":9001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GRPC.Addr != ":9001" {
+		t.Errorf("grpc.addr = %q, want the flag value", cfg.GRPC.Addr)
+	}
+	if cfg.Tokens.AccessTTL != 5*time.Minute {
+		t.Errorf("tokens.access_ttl = %v, want the file value", cfg.Tokens.AccessTTL)
+	}
+	if cfg.Tokens.RefreshTTL != 72*time.Hour {
+		t.Errorf("tokens.refresh_ttl = %v, want the env value", cfg.Tokens.RefreshTTL)
+	}
+}
+
+func TestLoadBoolFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"-ids-dev"}, true},
+		{[]string{"-ids-dev=true"}, true},
+		{[]string{"-ids-dev=false"}, false},
+		// флаг без значения не забирает следующий аргумент
+		{[]string{"-ids-dev", "-grpc-addr", ":9000"}, true},
+	}
+	for _, tt := range tests {
+		cfg, err := Load(Default(), tt.args)
+		if err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if cfg.IDs.Dev != tt.want {
+			t.Errorf("%v: ids.dev = %v, want %v", tt.args, cfg.IDs.Dev, tt.want)
+		}
+	}
+
+	if _, err := Load(Default(), []string{"-ids-dev=maybe"}); err == nil {
+		t.Error("-ids-dev=maybe: want error")
+	}
+}
+
+func TestLoadUnknownFileKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		unknown string
+	}{
+		{"misspelled setting", "config.yaml", "grpc:\n  adr: \":9000\"\n", "grpc.adr"},
+		{"unknown section", "config.yaml", "grcp:\n  addr: \":9000\"\n", "grcp"},
+		{"value instead of section", "config.yaml", "grpc: \":9000\"\n", "grpc"},
+		{"toml", "config.toml", "[tokens]\naccess_tll = \"5m\"\n", "tokens.access_tll"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(Default(), []string{"-config", writeFile(t, tt.file, tt.content)})
+			if err == nil || !strings.Contains(err.Error(), tt.unknown) {
+				t.Errorf("err = %v, want unknown setting %s", err, tt.unknown)
+			}
+		})
+	}
+}
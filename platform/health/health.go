@@ -0,0 +1,170 @@
+// Package health реализует readiness проверку: сервис готов принимать
+// трафик, только если доступны все его зависимости.
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	StatusOK   = "OK"
+	StatusFail = "FAIL"
+)
+
+// Check проверяет одну зависимость и возвращает ошибку, если она недоступна.
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"Status"`
+	Checks map[string]CheckResult `json:"Checks,omitempty"`
+}
+
+// Checker запускает зарегистрированные проверки параллельно,
+// каждую со своим таймаутом.
+type Checker struct {
+	timeout  time.Duration
+	draining atomic.Bool
+
+	mx     sync.RWMutex
+	checks map[string]Check
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, checks: make(map[string]Check)}
+}
+
+func (c *Checker) Add(name string, check Check) {
+	c.mx.Lock()
+	c.checks[name] = check
+	c.mx.Unlock()
+}
+
+// Drain переводит сервис в состояние "не готов" перед остановкой,
+// чтобы балансировщик перестал присылать новые запросы.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(c.checks)+1)}
+	if c.draining.Load() {
+		report.Status = StatusFail
+		report.Checks["shutdown"] = CheckResult{Status: StatusFail, Error: "service is shutting down"}
+	}
+
+	var (
+		wg sync.WaitGroup
+		mx sync.Mutex
+	)
+	for name, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			result := CheckResult{Status: StatusOK}
+			if err := check(ctx); err != nil {
+				result = CheckResult{Status: StatusFail, Error: err.Error()}
+			}
+
+			mx.Lock()
+			report.Checks[name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+			mx.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// Handler отдает результат проверок; 503, если хотя бы одна не прошла.
+func (c *Checker) Handler(ctx echo.Context) error {
+	report := c.Check(ctx.Request().Context())
+	if report.Status != StatusOK {
+		return ctx.JSON(http.StatusServiceUnavailable, report)
+	}
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// RunGRPC выполняет проверки каждые interval и выставляет по ним общий
+// статус grpc.health.v1 сервера s: NOT_SERVING, пока хотя бы одна
+// не проходит. Возвращается, когда ctx отменен.
+func (c *Checker) RunGRPC(ctx context.Context, s *grpchealth.Server, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	serving := true
+	for {
+		report := c.Check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		ok := report.Status == StatusOK
+		status := healthpb.HealthCheckResponse_SERVING
+		if !ok {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		s.SetServingStatus("", status)
+		switch {
+		case ok && !serving:
+			slog.InfoContext(ctx, "dependencies are available again, serving")
+		case !ok && serving:
+			slog.WarnContext(ctx, "dependency check failed, not serving", "checks", report.Checks)
+		}
+		serving = ok
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GRPC проверяет сервис за соединением через стандартный grpc.health.v1.
+func GRPC(conn grpc.ClientConnInterface) Check {
+	client := healthpb.NewHealthClient(conn)
+	return func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			return err
+		}
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			return errors.New(resp.GetStatus().String())
+		}
+		return nil
+	}
+}
+
+// Ping проверяет базу данных или другой ресурс с методом PingContext,
+// например *sql.DB.
+func Ping(p interface {
+	PingContext(ctx context.Context) error
+}) Check {
+	return p.PingContext
+}
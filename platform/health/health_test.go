@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func serving(t *testing.T, s *grpchealth.Server) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := s.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.GetStatus()
+}
+
+// waitStatus ждет, пока RunGRPC выставит статус want.
+func waitStatus(t *testing.T, s *grpchealth.Server, want healthpb.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for serving(t, s) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("status = %v, want %v", serving(t, s), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRunGRPC(t *testing.T) {
+	var down atomic.Bool
+	c := NewChecker(time.Second)
+	c.Add("database", func(context.Context) error {
+		if down.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	s := grpchealth.NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.RunGRPC(ctx, s, time.Millisecond)
+	}()
+
+	waitStatus(t, s, healthpb.HealthCheckResponse_SERVING)
+	down.Store(true)
+	waitStatus(t, s, healthpb.HealthCheckResponse_NOT_SERVING)
+	down.Store(false)
+	waitStatus(t, s, healthpb.HealthCheckResponse_SERVING)
+
+	// после Shutdown статус остается NOT_SERVING, что бы ни показали проверки
+	s.Shutdown()
+	time.Sleep(5 * time.Millisecond)
+	if got := serving(t, s); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("status after shutdown = %v, want NOT_SERVING", got)
+	}
+
+	cancel()
+	<-done
+}
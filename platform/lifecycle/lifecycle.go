@@ -0,0 +1,122 @@
+// Package lifecycle запускает серверы и останавливает их по сигналу,
+// давая текущим запросам завершиться.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/zura-t/go_messenger/platform/config"
+	"google.golang.org/grpc"
+)
+
+// SignalContext отменяется при получении SIGINT или SIGTERM.
+func SignalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// ServeEcho запускает e на адресе из cfg и блокируется до отмены ctx.
+// После этого вызываются onShutdown, сервер перестает принимать соединения
+// и ждет текущие запросы не дольше cfg.Shutdown.Timeout.
+func ServeEcho(ctx context.Context, e *echo.Echo, cfg config.Config, onShutdown ...func()) error {
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if cfg.TLS.Enabled() {
+			err = e.StartTLS(cfg.HTTP.Addr, cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		} else {
+			err = e.Start(cfg.HTTP.Addr)
+		}
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("shutting down, waiting up to %s for requests to finish", cfg.Shutdown.Timeout)
+	for _, fn := range onShutdown {
+		fn()
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Shutdown.Timeout)
+	defer cancel()
+
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// ServeGRPC обслуживает lis и блокируется до отмены ctx, после чего
+// вызывает onShutdown и GracefulStop. Если запросы не завершились
+// за timeout, соединения закрываются принудительно.
+// ServeHTTP обслуживает s, пока не отменен ctx, а затем останавливает его,
+// дожидаясь текущих запросов не дольше timeout.
+func ServeHTTP(ctx context.Context, s *http.Server, timeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.ListenAndServe() }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func ServeGRPC(ctx context.Context, s *grpc.Server, lis net.Listener, timeout time.Duration, onShutdown ...func()) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Serve(lis)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("shutting down, waiting up to %s for requests to finish", timeout)
+	for _, fn := range onShutdown {
+		fn()
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		log.Println("graceful shutdown timed out, closing remaining connections")
+		s.Stop()
+	}
+
+	return <-errCh
+}
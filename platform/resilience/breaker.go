@@ -0,0 +1,141 @@
+package resilience
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// State - состояние circuit breaker. Значения упорядочены по тяжести
+// и в таком виде попадают в метрики.
+type State int
+
+const (
+	// Closed - вызовы проходят, подряд идущие сбои считаются.
+	Closed State = iota
+	// HalfOpen - после паузы пропускается один пробный вызов,
+	// его результат закрывает или снова открывает breaker.
+	HalfOpen
+	// Open - вызовы сразу отклоняются, сервис не нагружается.
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case HalfOpen:
+		return "half-open"
+	case Open:
+		return "open"
+	}
+	return "unknown"
+}
+
+// ErrOpen возвращается вместо вызова, пока breaker открыт.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Outcome - итог вызова для breaker.
+type Outcome int
+
+const (
+	Success Outcome = iota
+	Failure
+	// Ignored - вызов ничего не говорит о сервисе, например его отменил клиент.
+	Ignored
+)
+
+// Breaker открывается после failures сбоев подряд и через cooldown
+// пропускает один пробный вызов. При failures = 0 он всегда закрыт.
+type Breaker struct {
+	failures int
+	cooldown time.Duration
+
+	mx       sync.Mutex
+	state    State
+	failed   int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreaker(failures int, cooldown time.Duration) *Breaker {
+	return &Breaker{failures: failures, cooldown: cooldown}
+}
+
+// Allow решает, можно ли сделать вызов. Если можно, итог вызова
+// нужно передать в done.
+func (b *Breaker) Allow() (done func(Outcome), err error) {
+	if b.failures <= 0 {
+		return func(Outcome) {}, nil
+	}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.cooldown {
+			return nil, ErrOpen
+		}
+		b.state = HalfOpen
+		fallthrough
+	case HalfOpen:
+		if b.probing {
+			return nil, ErrOpen
+		}
+		b.probing = true
+		return b.probeDone, nil
+	}
+	return b.done, nil
+}
+
+// done учитывает обычный вызов. Вызовы, начатые до открытия breaker,
+// на его состояние уже не влияют.
+func (b *Breaker) done(o Outcome) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	if b.state != Closed {
+		return
+	}
+	switch o {
+	case Success:
+		b.failed = 0
+	case Failure:
+		b.failed++
+		if b.failed >= b.failures {
+			b.open()
+		}
+	}
+}
+
+func (b *Breaker) probeDone(o Outcome) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	b.probing = false
+	switch o {
+	case Success:
+		b.state = Closed
+		b.failed = 0
+	case Failure:
+		b.open()
+	}
+}
+
+func (b *Breaker) open() {
+	b.state = Open
+	b.openedAt = time.Now()
+}
+
+// State возвращает текущее состояние. Открытый breaker, у которого
+// прошла пауза, считается полуоткрытым.
+func (b *Breaker) State() State {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	if b.state == Open && time.Since(b.openedAt) >= b.cooldown {
+		return HalfOpen
+	}
+	return b.state
+}
@@ -0,0 +1,130 @@
+package resilience
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const testCooldown = 20 * time.Millisecond
+
+// call делает вызов через b с итогом o и возвращает ошибку Allow.
+func call(b *Breaker, o Outcome) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	done(o)
+	return nil
+}
+
+func wantState(t *testing.T, b *Breaker, want State) {
+	t.Helper()
+	if got := b.State(); got != want {
+		t.Fatalf("state = %v, want %v", got, want)
+	}
+}
+
+// openBreaker возвращает breaker, открытый после трех сбоев подряд.
+func openBreaker(t *testing.T) *Breaker {
+	t.Helper()
+	b := NewBreaker(3, testCooldown)
+	for i := 0; i < 3; i++ {
+		if err := call(b, Failure); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	wantState(t, b, Open)
+	return b
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := NewBreaker(0, testCooldown)
+	for i := 0; i < 10; i++ {
+		if err := call(b, Failure); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	wantState(t, b, Closed)
+}
+
+func TestBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	b := NewBreaker(3, testCooldown)
+	// успех и игнорируемый вызов между сбоями: подряд их было не больше двух
+	for _, o := range []Outcome{Failure, Failure, Success, Failure, Ignored, Failure} {
+		if err := call(b, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wantState(t, b, Closed)
+
+	if err := call(b, Failure); err != nil {
+		t.Fatal(err)
+	}
+	wantState(t, b, Open)
+	if err := call(b, Success); !errors.Is(err, ErrOpen) {
+		t.Fatalf("err = %v, want %v", err, ErrOpen)
+	}
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		outcome Outcome
+		want    State
+	}{
+		{"success closes", Success, Closed},
+		{"failure opens again", Failure, Open},
+		{"ignored allows another probe", Ignored, HalfOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := openBreaker(t)
+			time.Sleep(testCooldown)
+			wantState(t, b, HalfOpen)
+
+			done, err := b.Allow()
+			if err != nil {
+				t.Fatalf("probe: %v", err)
+			}
+			// пока пробный вызов идет, остальные отклоняются
+			if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+				t.Fatalf("second call during probe: err = %v, want %v", err, ErrOpen)
+			}
+			done(tt.outcome)
+			wantState(t, b, tt.want)
+		})
+	}
+}
+
+func TestBreakerReopensForFullCooldown(t *testing.T) {
+	b := openBreaker(t)
+	time.Sleep(testCooldown)
+	if err := call(b, Failure); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if err := call(b, Success); !errors.Is(err, ErrOpen) {
+		t.Fatalf("err = %v, want %v", err, ErrOpen)
+	}
+	time.Sleep(testCooldown)
+	if err := call(b, Success); err != nil {
+		t.Fatalf("probe after cooldown: %v", err)
+	}
+	wantState(t, b, Closed)
+}
+
+func TestBreakerIgnoresCallsStartedBeforeOpening(t *testing.T) {
+	b := NewBreaker(1, testCooldown)
+	slow, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := call(b, Failure); err != nil {
+		t.Fatal(err)
+	}
+	wantState(t, b, Open)
+
+	// успех вызова, начатого до открытия, не закрывает breaker
+	slow(Success)
+	wantState(t, b, Open)
+}
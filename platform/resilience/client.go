@@ -0,0 +1,197 @@
+// Package resilience защищает вызовы другого сервиса по gRPC: у каждой
+// попытки свой дедлайн, идемпотентные вызовы повторяются с растущей
+// паузой со случайным разбросом, а circuit breaker перестает отправлять
+// вызовы в сервис, который не отвечает.
+package resilience
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zura-t/go_messenger/platform/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Stats - счетчики вызовов одного сервиса с момента запуска.
+type Stats struct {
+	State    State
+	Retries  uint64
+	Failures uint64
+	Rejected uint64
+}
+
+// Client применяет политику config.Backend ко всем вызовам через
+// соединение, на котором установлены его interceptor'ы.
+type Client struct {
+	name    string
+	policy  config.Backend
+	breaker *Breaker
+
+	retries  atomic.Uint64
+	failures atomic.Uint64
+	rejected atomic.Uint64
+
+	idempotent sync.Map // полное имя метода -> bool
+}
+
+func New(name string, policy config.Backend) *Client {
+	return &Client{
+		name:    name,
+		policy:  policy,
+		breaker: NewBreaker(policy.BreakerFailures, policy.BreakerCooldown),
+	}
+}
+
+func (c *Client) Name() string {
+	return c.name
+}
+
+func (c *Client) Stats() Stats {
+	return Stats{
+		State:    c.breaker.State(),
+		Retries:  c.retries.Load(),
+		Failures: c.failures.Load(),
+		Rejected: c.rejected.Load(),
+	}
+}
+
+// Check для readiness: сервис считается недоступным, пока breaker открыт.
+func (c *Client) Check(context.Context) error {
+	if c.breaker.State() == Open {
+		return ErrOpen
+	}
+	return nil
+}
+
+// DialOptions подключают политику к соединению или grpcpool.Pool.
+func (c *Client) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(c.unary),
+		grpc.WithChainStreamInterceptor(c.stream),
+	}
+}
+
+func (c *Client) unary(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	// проверки готовности должны видеть сам сервис, а не состояние breaker
+	if strings.HasPrefix(method, "/grpc.health.v1.Health/") {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	retries := 0
+	if c.isIdempotent(method) {
+		retries = c.policy.Retries
+	}
+
+	for attempt := 0; ; attempt++ {
+		done, err := c.breaker.Allow()
+		if err != nil {
+			c.rejected.Add(1)
+			return status.Errorf(codes.Unavailable, "%s: %v", c.name, err)
+		}
+
+		err = c.attempt(ctx, method, req, reply, cc, invoker, opts...)
+		done(c.outcome(ctx, err))
+		if err == nil || attempt >= retries || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		c.retries.Add(1)
+		timer := time.NewTimer(c.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if c.policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.policy.Timeout)
+		defer cancel()
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// stream не повторяет вызовы и не ограничивает их дедлайном: потоки
+// живут долго. Breaker учитывает только установку потока.
+func (c *Client) stream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	done, err := c.breaker.Allow()
+	if err != nil {
+		c.rejected.Add(1)
+		return nil, status.Errorf(codes.Unavailable, "%s: %v", c.name, err)
+	}
+
+	s, err := streamer(ctx, desc, cc, method, opts...)
+	done(c.outcome(ctx, err))
+	return s, err
+}
+
+// outcome относит к сбоям только ошибки, которые говорят о состоянии
+// сервиса, а не о самом запросе.
+func (c *Client) outcome(ctx context.Context, err error) Outcome {
+	if err == nil {
+		return Success
+	}
+	if ctx.Err() != nil {
+		return Ignored
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+		c.failures.Add(1)
+		return Failure
+	}
+	return Success
+}
+
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// backoff удваивает паузу с каждой попыткой и выбирает случайное значение
+// из ее второй половины, чтобы клиенты не повторяли вызовы одновременно.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.policy.BackoffMax
+	if attempt < 30 {
+		d = min(c.policy.BackoffBase<<attempt, c.policy.BackoffMax)
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// isIdempotent читает idempotency_level метода из дескриптора proto:
+// повторять можно только вызовы NO_SIDE_EFFECTS и IDEMPOTENT.
+func (c *Client) isIdempotent(method string) bool {
+	if v, ok := c.idempotent.Load(method); ok {
+		return v.(bool)
+	}
+
+	name := protoreflect.FullName(strings.ReplaceAll(strings.TrimPrefix(method, "/"), "/", "."))
+	idempotent := false
+	if d, err := protoregistry.GlobalFiles.FindDescriptorByName(name); err == nil {
+		if m, ok := d.(protoreflect.MethodDescriptor); ok {
+			opts, _ := m.Options().(*descriptorpb.MethodOptions)
+			idempotent = opts.GetIdempotencyLevel() != descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN
+		}
+	}
+
+	c.idempotent.Store(method, idempotent)
+	return idempotent
+}
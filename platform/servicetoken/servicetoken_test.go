@@ -0,0 +1,101 @@
+package servicetoken
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const internal = "/go_messenger.AccountsService/GetContacts"
+
+func call(t *testing.T, token, method string, md metadata.MD) codes.Code {
+	t.Helper()
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	handler := func(context.Context, any) (any, error) { return "ok", nil }
+	_, err := Require(token, internal)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	return status.Code(err)
+}
+
+func TestRequire(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		md     metadata.MD
+		want   codes.Code
+	}{
+		{"valid token", "secret", internal, metadata.Pairs(Header, "secret"), codes.OK},
+		{"no token", "secret", internal, metadata.MD{}, codes.Unauthenticated},
+		{"wrong token", "secret", internal, metadata.Pairs(Header, "guess"), codes.Unauthenticated},
+		{"two tokens", "secret", internal, metadata.Pairs(Header, "guess", Header, "secret"), codes.Unauthenticated},
+		{"server without token", "", internal, metadata.Pairs(Header, ""), codes.Unauthenticated},
+		{"public method", "secret", "/go_messenger.AccountsService/Login", metadata.MD{}, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := call(t, tt.token, tt.method, tt.md); got != tt.want {
+				t.Errorf("code = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type stream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s stream) Context() context.Context { return s.ctx }
+
+func TestRequireStream(t *testing.T) {
+	const watch = "/go_messenger.RelationsService/WatchRelations"
+	tests := []struct {
+		name   string
+		method string
+		md     metadata.MD
+		want   codes.Code
+	}{
+		{"valid token", watch, metadata.Pairs(Header, "secret"), codes.OK},
+		{"no token", watch, metadata.MD{}, codes.Unauthenticated},
+		{"wrong token", watch, metadata.Pairs(Header, "guess"), codes.Unauthenticated},
+		{"public method", "/grpc.health.v1.Health/Watch", metadata.MD{}, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss := stream{ctx: metadata.NewIncomingContext(context.Background(), tt.md)}
+			handler := func(any, grpc.ServerStream) error { return nil }
+			err := RequireStream("secret", watch)(nil, ss, &grpc.StreamServerInfo{FullMethod: tt.method}, handler)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("code = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMethods(t *testing.T) {
+	sd := grpc.ServiceDesc{
+		ServiceName: "go_messenger.RelationsService",
+		Methods:     []grpc.MethodDesc{{MethodName: "CheckBlock"}},
+		Streams:     []grpc.StreamDesc{{StreamName: "WatchRelations"}},
+	}
+	got := Methods(sd)
+	want := []string{"/go_messenger.RelationsService/CheckBlock", "/go_messenger.RelationsService/WatchRelations"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Methods = %q, want %q", got, want)
+	}
+}
+
+func TestAttach(t *testing.T) {
+	md, err := perRPC("secret").GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md[Header] != "secret" {
+		t.Errorf("metadata = %v, want %s: secret", md, Header)
+	}
+}
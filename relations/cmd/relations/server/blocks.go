@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	pb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *server) BlockUser(ctx context.Context, req *pb.BlockUserRequest) (*pb.BlockedUser, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetUserId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	at, err := s.friends.Block(ctx, user, req.GetUserId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &pb.BlockedUser{UserId: req.GetUserId(), BlockedAt: timestamppb.New(at)}, nil
+}
+
+func (s *server) UnblockUser(ctx context.Context, req *pb.UnblockUserRequest) (*pb.UnblockUserResponse, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.friends.Unblock(ctx, user, req.GetUserId()); err != nil {
+		return nil, statusError(err)
+	}
+	return &pb.UnblockUserResponse{Message: "user has been unblocked"}, nil
+}
+
+func (s *server) ListBlocked(ctx context.Context, _ *pb.ListBlockedRequest) (*pb.ListBlockedResponse, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := s.friends.ListBlocked(ctx, user)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list blocks", "error", err)
+		return nil, status.Error(codes.Internal, "failed to list blocked users")
+	}
+	resp := &pb.ListBlockedResponse{Users: make([]*pb.BlockedUser, 0, len(blocks))}
+	for _, b := range blocks {
+		resp.Users = append(resp.Users, &pb.BlockedUser{UserId: b.UserID, BlockedAt: timestamppb.New(b.BlockedAt)})
+	}
+	return resp, nil
+}
+
+// CheckBlock вызывают другие сервисы, а не пользователь, поэтому
+// оба id передаются в запросе.
+func (s *server) CheckBlock(ctx context.Context, req *pb.CheckBlockRequest) (*pb.CheckBlockResponse, error) {
+	if req.GetUserId() == 0 || req.GetTargetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id and target_id are required")
+	}
+
+	blocked, err := s.friends.Blocked(ctx, req.GetUserId(), req.GetTargetId())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to check block", "error", err)
+		return nil, status.Error(codes.Internal, "failed to check block")
+	}
+	blockedBy, err := s.friends.Blocked(ctx, req.GetTargetId(), req.GetUserId())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to check block", "error", err)
+		return nil, status.Error(codes.Internal, "failed to check block")
+	}
+	return &pb.CheckBlockResponse{Blocked: blocked, BlockedBy: blockedBy}, nil
+}
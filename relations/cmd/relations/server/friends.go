@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	apb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+	"github.com/zura-t/go_messenger/relations/internal/events"
+	"github.com/zura-t/go_messenger/relations/internal/friendship"
+	pb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// userIDHeader - метаданные, в которых api-gateway передает публичный id
+// пользователя, проверенный по его access токену. Им можно верить, потому
+// что без tokens.service_token вызов до обработчика не доходит.
+const userIDHeader = "x-user-id"
+
+type server struct {
+	pb.UnimplementedRelationsServiceServer
+
+	friends  *friendship.Store
+	events   events.Bus
+	accounts apb.AccountsServiceClient
+	// suggestLimit - сколько предложений друзей отдается за один вызов.
+	suggestLimit int
+}
+
+func NewServer(friends *friendship.Store, bus events.Bus, accounts apb.AccountsServiceClient, suggestLimit int) *server {
+	return &server{friends: friends, events: bus, accounts: accounts, suggestLimit: suggestLimit}
+}
+
+func (s *server) SendFriendRequest(ctx context.Context, req *pb.SendFriendRequestRequest) (*pb.Friendship, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetUserId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	f, err := s.friends.Send(ctx, user, req.GetUserId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(user, f), nil
+}
+
+func (s *server) RespondToFriendRequest(ctx context.Context, req *pb.RespondToFriendRequestRequest) (*pb.Friendship, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := s.friends.Respond(ctx, user, req.GetUserId(), req.GetAccept())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(user, f), nil
+}
+
+func (s *server) RemoveFriend(ctx context.Context, req *pb.RemoveFriendRequest) (*pb.Friendship, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := s.friends.Remove(ctx, user, req.GetUserId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(user, f), nil
+}
+
+const (
+	defaultPageSize = 50
+	// maxPageSize не больше лимита AccountsService.GetUsers,
+	// чтобы страницу можно было дополнить профилями одним вызовом.
+	maxPageSize = 100
+)
+
+func (s *server) ListFriends(ctx context.Context, req *pb.ListFriendsRequest) (*pb.ListFriendsResponse, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	size := int(req.GetPageSize())
+	switch {
+	case size == 0:
+		size = defaultPageSize
+	case size > maxPageSize:
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must not exceed %d", maxPageSize)
+	}
+
+	filter, ok := filters[req.GetFilter()]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "unknown filter")
+	}
+	q := friendship.Query{
+		Filter:      filter,
+		OldestFirst: req.GetOrder() == pb.FriendOrder_FRIEND_ORDER_OLDEST_FIRST,
+		Limit:       size + 1,
+	}
+	if req.GetPageToken() != "" {
+		q.After, err = decodePageToken(req.GetPageToken(), req.GetFilter(), req.GetOrder())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+	}
+
+	list, err := s.friends.List(ctx, user, q)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list friendships", "error", err)
+		return nil, status.Error(codes.Internal, "failed to list friends")
+	}
+	resp := &pb.ListFriendsResponse{}
+	if len(list) > size {
+		list = list[:size]
+		resp.NextPageToken = encodePageToken(list[size-1].Cursor(user), req.GetFilter(), req.GetOrder())
+	}
+
+	resp.Friends = make([]*pb.Friendship, 0, len(list))
+	for _, f := range list {
+		resp.Friends = append(resp.Friends, toProto(user, f))
+	}
+	if req.GetIncludeProfiles() {
+		s.addUsernames(ctx, resp.Friends)
+	}
+	return resp, nil
+}
+
+var filters = map[pb.FriendFilter]friendship.Filter{
+	pb.FriendFilter_FRIEND_FILTER_UNSPECIFIED:      friendship.All,
+	pb.FriendFilter_FRIEND_FILTER_ACCEPTED:         friendship.Friends,
+	pb.FriendFilter_FRIEND_FILTER_PENDING_INCOMING: friendship.Incoming,
+	pb.FriendFilter_FRIEND_FILTER_PENDING_OUTGOING: friendship.Outgoing,
+}
+
+// addUsernames дополняет связи именами пользователей.
+func (s *server) addUsernames(ctx context.Context, friends []*pb.Friendship) {
+	ids := make([]uint64, 0, len(friends))
+	for _, f := range friends {
+		ids = append(ids, f.GetUserId())
+	}
+	usernames := s.usernames(ctx, ids)
+	for _, f := range friends {
+		f.Username = usernames[f.GetUserId()]
+	}
+}
+
+// usernames загружает имена пользователей одним вызовом
+// AccountsService.GetUsers. Если accounts недоступен, имен нет.
+func (s *server) usernames(ctx context.Context, ids []uint64) map[uint64]string {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	resp, err := s.accounts.GetUsers(ctx, &apb.GetUsersRequest{Ids: ids})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to load friend profiles", "error", err)
+		return nil
+	}
+
+	usernames := make(map[uint64]string, len(resp.GetUsers()))
+	for _, u := range resp.GetUsers() {
+		usernames[u.GetId()] = u.GetUsername()
+	}
+	return usernames
+}
+
+// caller возвращает id пользователя, от имени которого пришел вызов.
+func caller(ctx context.Context) (uint64, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(userIDHeader)
+	if len(values) == 0 {
+		return 0, status.Error(codes.Unauthenticated, "user id is required")
+	}
+
+	id, err := strconv.ParseUint(values[0], 10, 64)
+	if err != nil || id == 0 {
+		return 0, status.Error(codes.Unauthenticated, "invalid user id")
+	}
+	return id, nil
+}
+
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, friendship.ErrSelf):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, friendship.ErrAlreadyFriends), errors.Is(err, friendship.ErrAlreadyRequested):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, friendship.ErrNotFound), errors.Is(err, friendship.ErrNotBlocked):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, friendship.ErrBlocked), errors.Is(err, friendship.ErrCooldown):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, friendship.ErrQuota):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		slog.Error("failed to update friendship", "error", err)
+		return status.Error(codes.Internal, "failed to update friendship")
+	}
+}
+
+// toProto показывает связь со стороны пользователя user.
+func toProto(user uint64, f friendship.Friendship) *pb.Friendship {
+	return &pb.Friendship{
+		UserId:    f.Other(user),
+		Status:    statuses[f.Status],
+		Outgoing:  f.Requester == user,
+		CreatedAt: timestamppb.New(f.CreatedAt),
+		UpdatedAt: timestamppb.New(f.UpdatedAt),
+	}
+}
+
+var statuses = map[friendship.Status]pb.FriendshipStatus{
+	friendship.Pending:  pb.FriendshipStatus_FRIENDSHIP_STATUS_PENDING,
+	friendship.Accepted: pb.FriendshipStatus_FRIENDSHIP_STATUS_ACCEPTED,
+	friendship.Rejected: pb.FriendshipStatus_FRIENDSHIP_STATUS_REJECTED,
+	friendship.Removed:  pb.FriendshipStatus_FRIENDSHIP_STATUS_REMOVED,
+	friendship.Expired:  pb.FriendshipStatus_FRIENDSHIP_STATUS_EXPIRED,
+	// в API отозванная заявка - удаленная
+	friendship.Withdrawn: pb.FriendshipStatus_FRIENDSHIP_STATUS_REMOVED,
+}
+
+var relationStates = map[friendship.RelationState]pb.RelationState{
+	friendship.RelationNone:    pb.RelationState_RELATION_STATE_NONE,
+	friendship.RelationPending: pb.RelationState_RELATION_STATE_PENDING,
+	friendship.RelationFriends: pb.RelationState_RELATION_STATE_FRIEND,
+	friendship.RelationBlocked: pb.RelationState_RELATION_STATE_BLOCKED,
+}
+
+// CheckRelation, как и CheckBlock, вызывают другие сервисы, поэтому
+// оба id передаются в запросе.
+func (s *server) CheckRelation(ctx context.Context, req *pb.CheckRelationRequest) (*pb.CheckRelationResponse, error) {
+	if req.GetUserId() == 0 || req.GetTargetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id and target_id are required")
+	}
+	if req.GetUserId() == req.GetTargetId() {
+		return nil, status.Error(codes.InvalidArgument, "user_id and target_id must be different users")
+	}
+
+	r, err := s.friends.Relation(ctx, req.GetUserId(), req.GetTargetId())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to check relation", "error", err)
+		return nil, status.Error(codes.Internal, "failed to check relation")
+	}
+	return &pb.CheckRelationResponse{State: relationStates[r.State], Outgoing: r.Outgoing}, nil
+}
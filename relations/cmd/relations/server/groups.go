@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/zura-t/go_messenger/relations/internal/friendship"
+	pb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxGroupBatch ограничивает число друзей, добавляемых одним вызовом.
+const maxGroupBatch = 100
+
+func (s *server) CreateGroup(ctx context.Context, req *pb.CreateGroupRequest) (*pb.FriendGroup, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.GetUserIds()) > maxGroupBatch {
+		return nil, status.Errorf(codes.InvalidArgument, "user_ids must not contain more than %d ids", maxGroupBatch)
+	}
+
+	g, err := s.friends.CreateGroup(ctx, user, req.GetName(), req.GetUserIds())
+	if err != nil {
+		return nil, groupError(err)
+	}
+	return groupToProto(g), nil
+}
+
+func (s *server) ListGroups(ctx context.Context, _ *pb.ListGroupsRequest) (*pb.ListGroupsResponse, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.friends.Groups(ctx, user)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list groups", "error", err)
+		return nil, status.Error(codes.Internal, "failed to list groups")
+	}
+	resp := &pb.ListGroupsResponse{Groups: make([]*pb.FriendGroup, 0, len(groups))}
+	for _, g := range groups {
+		resp.Groups = append(resp.Groups, groupToProto(g))
+	}
+	return resp, nil
+}
+
+func (s *server) GetGroup(ctx context.Context, req *pb.GetGroupRequest) (*pb.FriendGroup, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := s.friends.Group(ctx, user, req.GetGroupId())
+	if err != nil {
+		return nil, groupError(err)
+	}
+	return groupToProto(g), nil
+}
+
+func (s *server) RenameGroup(ctx context.Context, req *pb.RenameGroupRequest) (*pb.FriendGroup, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := s.friends.RenameGroup(ctx, user, req.GetGroupId(), req.GetName())
+	if err != nil {
+		return nil, groupError(err)
+	}
+	return groupToProto(g), nil
+}
+
+func (s *server) DeleteGroup(ctx context.Context, req *pb.DeleteGroupRequest) (*pb.DeleteGroupResponse, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.friends.DeleteGroup(ctx, user, req.GetGroupId()); err != nil {
+		return nil, groupError(err)
+	}
+	return &pb.DeleteGroupResponse{Message: "group has been deleted"}, nil
+}
+
+func (s *server) AddGroupMembers(ctx context.Context, req *pb.AddGroupMembersRequest) (*pb.FriendGroup, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.GetUserIds()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_ids are required")
+	}
+	if len(req.GetUserIds()) > maxGroupBatch {
+		return nil, status.Errorf(codes.InvalidArgument, "user_ids must not contain more than %d ids", maxGroupBatch)
+	}
+
+	g, err := s.friends.AddGroupMembers(ctx, user, req.GetGroupId(), req.GetUserIds())
+	if err != nil {
+		return nil, groupError(err)
+	}
+	return groupToProto(g), nil
+}
+
+func (s *server) RemoveGroupMember(ctx context.Context, req *pb.RemoveGroupMemberRequest) (*pb.FriendGroup, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := s.friends.RemoveGroupMember(ctx, user, req.GetGroupId(), req.GetUserId())
+	if err != nil {
+		return nil, groupError(err)
+	}
+	return groupToProto(g), nil
+}
+
+// groupError переводит ошибку групп в gRPC статус.
+func groupError(err error) error {
+	switch {
+	case errors.Is(err, friendship.ErrGroupName):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, friendship.ErrGroupExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, friendship.ErrGroupNotFound), errors.Is(err, friendship.ErrNotMember):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, friendship.ErrNotFriend):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, friendship.ErrGroupLimit), errors.Is(err, friendship.ErrMemberLimit):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		slog.Error("failed to update friend group", "error", err)
+		return status.Error(codes.Internal, "failed to update friend group")
+	}
+}
+
+func groupToProto(g friendship.Group) *pb.FriendGroup {
+	return &pb.FriendGroup{
+		Id:        g.ID,
+		Name:      g.Name,
+		MemberIds: g.Members,
+		CreatedAt: timestamppb.New(g.CreatedAt),
+		UpdatedAt: timestamppb.New(g.UpdatedAt),
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"os"
+	"time"
+
+	apb "github.com/zura-t/go_messenger/accounts/pkg/accounts"
+	"github.com/zura-t/go_messenger/platform/config"
+	"github.com/zura-t/go_messenger/platform/grpcpool"
+	"github.com/zura-t/go_messenger/platform/health"
+	"github.com/zura-t/go_messenger/platform/lifecycle"
+	"github.com/zura-t/go_messenger/platform/resilience"
+	"github.com/zura-t/go_messenger/platform/servicetoken"
+	"github.com/zura-t/go_messenger/relations/internal/events"
+	"github.com/zura-t/go_messenger/relations/internal/friendship"
+	pb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// eventBuffer - сколько событий ждут подписчика, прежде чем он будет отписан.
+const eventBuffer = 1024
+
+const (
+	// healthInterval - как часто проверяется база для grpc.health.v1
+	healthInterval = 5 * time.Second
+	healthTimeout  = 2 * time.Second
+)
+
+func main() {
+	defaults := config.Default()
+	defaults.GRPC.Addr = ":8083"
+	defaults.Endpoints.Accounts = "localhost:8081"
+
+	cfg, err := config.Load(defaults, os.Args[1:])
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if err := cfg.Require("grpc.addr", "endpoints.accounts"); err != nil {
+		log.Fatal(err)
+	}
+	slog.SetLogLoggerLevel(cfg.Log.SlogLevel())
+	log.Printf("config:\n%s", cfg)
+
+	lis, err := net.Listen("tcp", cfg.GRPC.Addr)
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+
+	clientCreds := insecure.NewCredentials()
+	if cfg.TLS.CAFile != "" {
+		clientCreds, err = credentials.NewClientTLSFromFile(cfg.TLS.CAFile, "")
+		if err != nil {
+			log.Fatalf("failed to load CA certificate: %v", err)
+		}
+	}
+
+	// accounts нужен только для имен в ListFriends
+	accountsPolicy := resilience.New("accounts", cfg.Backends.Accounts)
+	accountsConn, err := grpcpool.New(cfg.Endpoints.Accounts, cfg.Endpoints.PoolSize,
+		append(accountsPolicy.DialOptions(), grpc.WithTransportCredentials(clientCreds))...)
+	if err != nil {
+		log.Fatalf("failed to create accounts client: %v", err)
+	}
+	defer accountsConn.Close()
+
+	ctx, stop := lifecycle.SignalContext()
+	defer stop()
+
+	checker := health.NewChecker(healthTimeout)
+	repo, closeRepo, err := newRepository(ctx, cfg.Database, checker)
+	if err != nil {
+		log.Fatalf("failed to open relations storage: %v", err)
+	}
+	defer closeRepo()
+
+	var opts []grpc.ServerOption
+	if cfg.TLS.Enabled() {
+		creds, err := credentials.NewServerTLSFromFile(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			log.Fatalf("failed to load TLS certificate: %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	// вызовы от имени пользователя доверяют x-user-id, а CheckBlock,
+	// CheckRelation и WatchRelations отдают связи любых пользователей,
+	// поэтому RelationsService отвечает только сервисам с tokens.service_token
+	if cfg.Tokens.ServiceToken == "" {
+		log.Println("tokens.service_token is not set, all RelationsService calls are refused")
+	}
+	methods := servicetoken.Methods(pb.RelationsService_ServiceDesc)
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(servicetoken.Require(cfg.Tokens.ServiceToken, methods...)),
+		grpc.ChainStreamInterceptor(servicetoken.RequireStream(cfg.Tokens.ServiceToken, methods...)))
+
+	server := grpc.NewServer(opts...)
+	// события получают только подписчики этой реплики
+	bus := events.NewLocal(eventBuffer)
+	friends := friendship.NewStore(repo, bus, friendship.Rules{
+		SuggestScan:       cfg.Relations.SuggestScan,
+		MutualScan:        cfg.Relations.MutualScan,
+		DailyRequests:     cfg.Relations.DailyRequests,
+		RejectionCooldown: cfg.Relations.RejectionCooldown,
+		RequestTTL:        cfg.Relations.RequestTTL,
+		MaxGroups:         cfg.Relations.MaxGroups,
+		MaxGroupMembers:   cfg.Relations.MaxGroupMembers,
+	})
+	pb.RegisterRelationsServiceServer(server, NewServer(friends, bus, apb.NewAccountsServiceClient(accountsConn), cfg.Relations.SuggestLimit))
+
+	// для readiness проверок: api-gateway и kubernetes спрашивают grpc.health.v1
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go checker.RunGRPC(ctx, healthServer, healthInterval)
+
+	reflection.Register(server)
+
+	// хранилище закрывается только после того, как sweeper остановится
+	sweeper := make(chan struct{})
+	go func() {
+		defer close(sweeper)
+		friends.RunSweeper(ctx, cfg.Relations.SweepInterval)
+	}()
+
+	log.Printf("server listening at %v", lis.Addr())
+	if err := lifecycle.ServeGRPC(ctx, server, lis, cfg.Shutdown.Timeout, healthServer.Shutdown, bus.Close); err != nil {
+		log.Fatalf("failed to serve: %v", err)
+	}
+	<-sweeper
+}
+
+// newRepository выбирает хранилище связей: PostgreSQL, если задан
+// database.dsn, с миграцией схемы до последней версии, иначе память процесса.
+// Проверка доступности базы добавляется в checker.
+func newRepository(ctx context.Context, db config.Database, checker *health.Checker) (friendship.Repository, func(), error) {
+	if db.DSN == "" {
+		log.Println("database.dsn is not set, relations are kept in memory")
+		return friendship.NewMemory(), func() {}, nil
+	}
+
+	pool, err := pgxpool.New(ctx, db.DSN)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := friendship.Migrate(ctx, pool); err != nil {
+		pool.Close()
+		return nil, nil, fmt.Errorf("migrate: %w", err)
+	}
+	checker.Add("database", pool.Ping)
+	return friendship.NewPostgres(pool), pool.Close, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/zura-t/go_messenger/relations/internal/friendship"
+	pb "github.com/zura-t/go_messenger/relations/pkg/relations"
+)
+
+// pageToken - позиция в списке вместе с параметрами запроса: с другим
+// фильтром или порядком продолжать список с этой позиции нельзя.
+type pageToken struct {
+	Filter    pb.FriendFilter `json:"f"`
+	Order     pb.FriendOrder  `json:"o"`
+	UpdatedAt int64           `json:"t"`
+	UserID    uint64          `json:"u"`
+}
+
+func encodePageToken(c friendship.Cursor, filter pb.FriendFilter, order pb.FriendOrder) string {
+	raw, _ := json.Marshal(pageToken{Filter: filter, Order: order, UpdatedAt: c.UpdatedAt.UnixNano(), UserID: c.UserID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodePageToken(token string, filter pb.FriendFilter, order pb.FriendOrder) (*friendship.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var t pageToken
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, err
+	}
+	if t.Filter != filter || t.Order != order {
+		return nil, errors.New("page token was issued for another filter or order")
+	}
+	if t.UpdatedAt == 0 || t.UserID == 0 {
+		return nil, errors.New("page token has no position")
+	}
+	return &friendship.Cursor{UpdatedAt: time.Unix(0, t.UpdatedAt), UserID: t.UserID}, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_messenger/relations/internal/events"
+	"github.com/zura-t/go_messenger/relations/internal/friendship"
+	pb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestPageTokenRoundTrip(t *testing.T) {
+	cursors := []friendship.Cursor{
+		{UpdatedAt: time.Date(2025, 3, 1, 12, 30, 0, 123456000, time.UTC), UserID: 42},
+		{UpdatedAt: time.Unix(1, 1), UserID: math.MaxUint64},
+	}
+	for _, c := range cursors {
+		token := encodePageToken(c, pb.FriendFilter_FRIEND_FILTER_ACCEPTED, pb.FriendOrder_FRIEND_ORDER_OLDEST_FIRST)
+		got, err := decodePageToken(token, pb.FriendFilter_FRIEND_FILTER_ACCEPTED, pb.FriendOrder_FRIEND_ORDER_OLDEST_FIRST)
+		if err != nil {
+			t.Fatalf("decode %q: %v", token, err)
+		}
+		if !got.UpdatedAt.Equal(c.UpdatedAt) || got.UserID != c.UserID {
+			t.Errorf("decode(encode(%v)) = %v", c, *got)
+		}
+	}
+}
+
+func TestPageTokenRejected(t *testing.T) {
+	valid := encodePageToken(friendship.Cursor{UpdatedAt: time.Unix(1700000000, 0), UserID: 7},
+		pb.FriendFilter_FRIEND_FILTER_ACCEPTED, pb.FriendOrder_FRIEND_ORDER_NEWEST_FIRST)
+	encode := func(json string) string { return base64.RawURLEncoding.EncodeToString([]byte(json)) }
+
+	tests := []struct {
+		name   string
+		token  string
+		filter pb.FriendFilter
+		order  pb.FriendOrder
+	}{
+		{"another filter", valid, pb.FriendFilter_FRIEND_FILTER_PENDING_INCOMING, pb.FriendOrder_FRIEND_ORDER_NEWEST_FIRST},
+		{"another order", valid, pb.FriendFilter_FRIEND_FILTER_ACCEPTED, pb.FriendOrder_FRIEND_ORDER_OLDEST_FIRST},
+		{"not base64", "!!" + valid, pb.FriendFilter_FRIEND_FILTER_ACCEPTED, pb.FriendOrder_FRIEND_ORDER_NEWEST_FIRST},
+		{"truncated", valid[:len(valid)-4], pb.FriendFilter_FRIEND_FILTER_ACCEPTED, pb.FriendOrder_FRIEND_ORDER_NEWEST_FIRST},
+		{"not json", encode("page 2"), 0, 0},
+		{"wrong types", encode(`{"t":"yesterday","u":-1}`), 0, 0},
+		{"no position", encode(`{}`), 0, 0},
+		{"no user", encode(`{"t":1700000000000000000}`), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := decodePageToken(tt.token, tt.filter, tt.order); err == nil {
+				t.Errorf("decodePageToken(%q) = %v, want error", tt.token, *c)
+			}
+		})
+	}
+}
+
+func TestListFriendsPages(t *testing.T) {
+	const user = 1
+	store := friendship.NewStore(friendship.NewMemory(), events.NewLocal(16), friendship.Rules{RequestTTL: time.Hour})
+	s := NewServer(store, events.NewLocal(16), nil, 10)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(userIDHeader, strconv.Itoa(user)))
+
+	const friends = 7
+	for other := uint64(2); other < 2+friends; other++ {
+		if _, err := store.Send(context.Background(), user, other); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Respond(context.Background(), other, user, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, order := range []pb.FriendOrder{pb.FriendOrder_FRIEND_ORDER_NEWEST_FIRST, pb.FriendOrder_FRIEND_ORDER_OLDEST_FIRST} {
+		seen := make(map[uint64]bool)
+		req := &pb.ListFriendsRequest{Filter: pb.FriendFilter_FRIEND_FILTER_ACCEPTED, Order: order, PageSize: 3}
+		pages := 0
+		for {
+			resp, err := s.ListFriends(ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pages++
+			for _, f := range resp.GetFriends() {
+				if seen[f.GetUserId()] {
+					t.Fatalf("%v: friend %d listed twice", order, f.GetUserId())
+				}
+				seen[f.GetUserId()] = true
+			}
+			if resp.GetNextPageToken() == "" {
+				break
+			}
+			req.PageToken = resp.GetNextPageToken()
+		}
+		if len(seen) != friends || pages != 3 {
+			t.Errorf("%v: %d friends on %d pages, want %d on 3", order, len(seen), pages, friends)
+		}
+	}
+
+	// токен страницы с другим порядком отклоняется
+	resp, err := s.ListFriends(ctx, &pb.ListFriendsRequest{Filter: pb.FriendFilter_FRIEND_FILTER_ACCEPTED, PageSize: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.ListFriends(ctx, &pb.ListFriendsRequest{
+		Filter:    pb.FriendFilter_FRIEND_FILTER_ACCEPTED,
+		Order:     pb.FriendOrder_FRIEND_ORDER_OLDEST_FIRST,
+		PageSize:  3,
+		PageToken: resp.GetNextPageToken(),
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("token for another order: %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	pb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultMutualLimit = 10
+	maxMutualLimit     = 100
+)
+
+func (s *server) SuggestFriends(ctx context.Context, req *pb.SuggestFriendsRequest) (*pb.SuggestFriendsResponse, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(req.GetLimit())
+	if limit == 0 || limit > s.suggestLimit {
+		limit = s.suggestLimit
+	}
+
+	suggestions, err := s.friends.Suggest(ctx, user, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to suggest friends", "error", err)
+		return nil, status.Error(codes.Internal, "failed to suggest friends")
+	}
+
+	resp := &pb.SuggestFriendsResponse{Suggestions: make([]*pb.FriendSuggestion, 0, len(suggestions))}
+	ids := make([]uint64, 0, len(suggestions))
+	for _, sg := range suggestions {
+		resp.Suggestions = append(resp.Suggestions, &pb.FriendSuggestion{UserId: sg.UserID, MutualFriends: uint32(sg.Mutual)})
+		ids = append(ids, sg.UserID)
+	}
+	if req.GetIncludeProfiles() {
+		usernames := s.usernames(ctx, ids)
+		for _, sg := range resp.Suggestions {
+			sg.Username = usernames[sg.GetUserId()]
+		}
+	}
+	return resp, nil
+}
+
+func (s *server) MutualFriends(ctx context.Context, req *pb.MutualFriendsRequest) (*pb.MutualFriendsResponse, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetUserId() == 0 || req.GetUserId() == user {
+		return nil, status.Error(codes.InvalidArgument, "user_id of another user is required")
+	}
+
+	limit := int(req.GetLimit())
+	switch {
+	case limit == 0:
+		limit = defaultMutualLimit
+	case limit > maxMutualLimit:
+		return nil, status.Errorf(codes.InvalidArgument, "limit must not exceed %d", maxMutualLimit)
+	}
+
+	mutual, err := s.friends.Mutual(ctx, user, req.GetUserId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &pb.MutualFriendsResponse{Count: uint32(len(mutual))}
+	mutual = mutual[:min(limit, len(mutual))]
+	var usernames map[uint64]string
+	if req.GetIncludeProfiles() {
+		usernames = s.usernames(ctx, mutual)
+	}
+	resp.Friends = make([]*pb.MutualFriend, 0, len(mutual))
+	for _, id := range mutual {
+		resp.Friends = append(resp.Friends, &pb.MutualFriend{UserId: id, Username: usernames[id]})
+	}
+	return resp, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/zura-t/go_messenger/relations/internal/events"
+	pb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WatchRelations вызывают другие сервисы, поэтому x-user-id не нужен.
+func (s *server) WatchRelations(req *pb.WatchRelationsRequest, stream pb.RelationsService_WatchRelationsServer) error {
+	ctx := stream.Context()
+	var users map[uint64]bool
+	if len(req.GetUserIds()) > 0 {
+		users = make(map[uint64]bool, len(req.GetUserIds()))
+		for _, id := range req.GetUserIds() {
+			users[id] = true
+		}
+	}
+
+	sub, err := s.events.Subscribe(ctx)
+	if err != nil {
+		return subscriptionError(err)
+	}
+	defer sub.Close()
+	// заголовки уходят после подписки: получив их, подписчик знает, что
+	// дальше не пропустит ни одного события
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case e, ok := <-sub.Events():
+			if !ok {
+				return subscriptionError(sub.Err())
+			}
+			if users != nil && !users[e.Actor] && !users[e.Target] {
+				continue
+			}
+			if err := stream.Send(eventToProto(e)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// subscriptionError сообщает, почему оборвалась подписка. UNAVAILABLE
+// подсказывает подписчику подписаться снова, возможно на другой реплике.
+func subscriptionError(err error) error {
+	switch {
+	case errors.Is(err, events.ErrSlow), errors.Is(err, events.ErrClosed):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		slog.Error("relation events subscription failed", "error", err)
+		return status.Error(codes.Internal, "relation events subscription failed")
+	}
+}
+
+func eventToProto(e events.Event) *pb.RelationEvent {
+	return &pb.RelationEvent{
+		Id:         e.ID,
+		Type:       eventTypes[e.Kind],
+		ActorId:    e.Actor,
+		TargetId:   e.Target,
+		OccurredAt: timestamppb.New(e.At),
+	}
+}
+
+var eventTypes = map[events.Kind]pb.RelationEventType{
+	events.RequestSent:       pb.RelationEventType_RELATION_EVENT_TYPE_REQUEST_SENT,
+	events.RequestAccepted:   pb.RelationEventType_RELATION_EVENT_TYPE_REQUEST_ACCEPTED,
+	events.RequestRejected:   pb.RelationEventType_RELATION_EVENT_TYPE_REQUEST_REJECTED,
+	events.FriendshipRemoved: pb.RelationEventType_RELATION_EVENT_TYPE_FRIENDSHIP_REMOVED,
+	events.UserBlocked:       pb.RelationEventType_RELATION_EVENT_TYPE_USER_BLOCKED,
+	events.UserUnblocked:     pb.RelationEventType_RELATION_EVENT_TYPE_USER_UNBLOCKED,
+}
@@ -0,0 +1,148 @@
+// Package events описывает события связей пользователей и шину,
+// через которую их получают подписчики.
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type Kind int
+
+const (
+	RequestSent Kind = iota + 1
+	RequestAccepted
+	RequestRejected
+	// FriendshipRemoved - друг удален или заявка отозвана.
+	FriendshipRemoved
+	UserBlocked
+	UserUnblocked
+)
+
+// Event - изменение связи Actor с Target, которое сделал Actor.
+type Event struct {
+	// ID растет в пределах шины: по пропуску подписчик видит, что потерял события.
+	ID     uint64
+	Kind   Kind
+	Actor  uint64
+	Target uint64
+	At     time.Time
+}
+
+var (
+	// ErrSlow - подписчик не успевал забирать события и был отписан.
+	ErrSlow = errors.New("subscriber is too slow, events were dropped")
+	// ErrClosed - шина закрыта, подписаться на нее нельзя.
+	ErrClosed = errors.New("event bus is closed")
+)
+
+// Publisher публикует события. ID события назначает шина.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// Bus доставляет события подписчикам. Local работает внутри процесса;
+// брокер между репликами подключается другой реализацией Bus.
+type Bus interface {
+	Publisher
+	Subscribe(ctx context.Context) (Subscription, error)
+}
+
+// Subscription - поток событий, опубликованных после подписки.
+type Subscription interface {
+	// Events закрывается после Close или когда подписка оборвалась,
+	// причина - в Err.
+	Events() <-chan Event
+	Err() error
+	Close()
+}
+
+// Local раздает события подписчикам этого процесса. Publish не ждет
+// подписчиков: тот, чей буфер переполнен, отписывается с ErrSlow.
+type Local struct {
+	buffer int
+
+	mx     sync.Mutex
+	lastID uint64
+	subs   map[*localSubscription]struct{}
+	closed bool
+}
+
+// NewLocal создает шину, у каждого подписчика которой буфер на buffer событий.
+func NewLocal(buffer int) *Local {
+	return &Local{buffer: buffer, subs: make(map[*localSubscription]struct{})}
+}
+
+func (l *Local) Publish(_ context.Context, e Event) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.lastID++
+	e.ID = l.lastID
+	for s := range l.subs {
+		select {
+		case s.ch <- e:
+		default:
+			l.dropLocked(s, ErrSlow)
+		}
+	}
+	return nil
+}
+
+func (l *Local) Subscribe(_ context.Context) (Subscription, error) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if l.closed {
+		return nil, ErrClosed
+	}
+	s := &localSubscription{bus: l, ch: make(chan Event, l.buffer)}
+	l.subs[s] = struct{}{}
+	return s, nil
+}
+
+// Close обрывает подписки с ErrClosed, чтобы потоки подписчиков не
+// задерживали остановку сервера. Публиковать после Close можно, но некому.
+func (l *Local) Close() {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.closed = true
+	for s := range l.subs {
+		l.dropLocked(s, ErrClosed)
+	}
+}
+
+func (l *Local) dropLocked(s *localSubscription, err error) {
+	if _, ok := l.subs[s]; !ok {
+		return
+	}
+	delete(l.subs, s)
+	s.err = err
+	close(s.ch)
+}
+
+type localSubscription struct {
+	bus *Local
+	ch  chan Event
+	// err пишется под bus.mx до закрытия ch
+	err error
+}
+
+func (s *localSubscription) Events() <-chan Event {
+	return s.ch
+}
+
+func (s *localSubscription) Err() error {
+	s.bus.mx.Lock()
+	defer s.bus.mx.Unlock()
+	return s.err
+}
+
+func (s *localSubscription) Close() {
+	s.bus.mx.Lock()
+	defer s.bus.mx.Unlock()
+	s.bus.dropLocked(s, nil)
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLocalDropsSlowSubscriber(t *testing.T) {
+	ctx := context.Background()
+	bus := NewLocal(2)
+	slow, err := bus.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, err := bus.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fast.Close()
+
+	// Publish не должен ждать slow, который ничего не читает
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := uint64(1); i <= 4; i++ {
+			if err := bus.Publish(ctx, Event{Kind: RequestSent, Actor: i}); err != nil {
+				t.Error(err)
+			}
+			if e := <-fast.Events(); e.ID != i {
+				t.Errorf("fast subscriber got event %d, want %d", e.ID, i)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a slow subscriber")
+	}
+
+	// slow получает то, что поместилось в буфер, и отписан с ErrSlow
+	var ids []uint64
+	for e := range slow.Events() {
+		ids = append(ids, e.ID)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("slow subscriber got events %v, want [1 2]", ids)
+	}
+	if !errors.Is(slow.Err(), ErrSlow) {
+		t.Errorf("slow subscriber err = %v, want %v", slow.Err(), ErrSlow)
+	}
+	if fast.Err() != nil {
+		t.Errorf("fast subscriber err = %v, want nil", fast.Err())
+	}
+}
+
+func TestLocalClose(t *testing.T) {
+	ctx := context.Background()
+	bus := NewLocal(1)
+	closed, err := bus.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	if _, ok := <-closed.Events(); ok || closed.Err() != nil {
+		t.Errorf("after Close: open = %v, err = %v, want closed without error", ok, closed.Err())
+	}
+
+	sub, err := bus.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus.Close()
+	if _, ok := <-sub.Events(); ok || !errors.Is(sub.Err(), ErrClosed) {
+		t.Errorf("after bus Close: open = %v, err = %v, want %v", ok, sub.Err(), ErrClosed)
+	}
+	if _, err := bus.Subscribe(ctx); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe after Close: err = %v, want %v", err, ErrClosed)
+	}
+}
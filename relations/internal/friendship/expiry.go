@@ -0,0 +1,52 @@
+package friendship
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// sweepBatch - сколько заявок истекает за один запрос к хранилищу,
+// чтобы не блокировать все просроченные заявки разом.
+const sweepBatch = 500
+
+// ExpireRequests переводит в Expired заявки, которые ждут ответа
+// дольше Rules.RequestTTL, и возвращает, сколько их было.
+func (s *Store) ExpireRequests(ctx context.Context) (int, error) {
+	before := now().Add(-s.rules.RequestTTL)
+
+	total := 0
+	for {
+		n, err := s.repo.ExpirePending(ctx, before, now(), sweepBatch)
+		total += n
+		if err != nil || n < sweepBatch {
+			return total, err
+		}
+	}
+}
+
+// RunSweeper каждые interval снимает просроченные заявки и чистит журнал
+// квоты; возвращается, когда ctx отменен и текущий проход прерван.
+func (s *Store) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.ExpireRequests(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			slog.ErrorContext(ctx, "failed to expire friend requests", "error", err)
+		case n > 0:
+			slog.InfoContext(ctx, "expired friend requests", "count", n)
+		}
+		if err := s.repo.PruneSent(ctx, now().Add(-quotaWindow)); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to prune friend request log", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,467 @@
+// Package friendship хранит заявки в друзья и следит, чтобы они
+// переходили только между допустимыми состояниями.
+package friendship
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/zura-t/go_messenger/relations/internal/events"
+)
+
+type Status int
+
+// Значения Status хранятся в базе, их нельзя менять.
+const (
+	Pending Status = iota + 1
+	Accepted
+	Rejected
+	Removed
+	// Expired - на заявку не ответили за Rules.RequestTTL.
+	Expired
+	// Withdrawn - отправитель отозвал заявку до ответа.
+	Withdrawn
+)
+
+var (
+	ErrSelf             = errors.New("cannot send a friend request to yourself")
+	ErrAlreadyFriends   = errors.New("users are already friends")
+	ErrAlreadyRequested = errors.New("friend request has already been sent")
+	ErrNotFound         = errors.New("friend request not found")
+	ErrBlocked          = errors.New("user is blocked, unblock them first")
+	ErrNotBlocked       = errors.New("user is not blocked")
+	ErrQuota            = errors.New("daily friend request limit reached")
+	ErrCooldown         = errors.New("friend request was rejected or withdrawn recently, try again later")
+)
+
+// Friendship - связь двух пользователей. Requester отправил заявку,
+// Addressee ее получил.
+type Friendship struct {
+	Requester uint64
+	Addressee uint64
+	Status    Status
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Other возвращает второго участника связи.
+func (f Friendship) Other(user uint64) uint64 {
+	if f.Requester == user {
+		return f.Addressee
+	}
+	return f.Requester
+}
+
+// Pair - все, что хранится об одной паре пользователей.
+type Pair struct {
+	// Friendship - связь пары; nil, если ее еще не было.
+	Friendship *Friendship
+	// Blocks - кто из пары заблокировал другого и когда.
+	Blocks map[uint64]time.Time
+}
+
+// Repository хранит связи, блокировки и группы друзей. Memory подходит
+// для одной реплики, Postgres делит данные между репликами и переживает
+// перезапуск. Когда Update заканчивает дружбу, пара удаляется из групп
+// друг друга в том же изменении, а новую заявку Update записывает
+// в журнал квоты.
+type Repository interface {
+	// Update читает пару a, b, передает ее fn и сохраняет изменения,
+	// если fn не вернула ошибку. Изменения одной пары идут по очереди.
+	Update(ctx context.Context, a, b uint64, fn func(p *Pair) error) error
+	// Pair читает пару a, b, не дожидаясь ее изменений.
+	Pair(ctx context.Context, a, b uint64) (Pair, error)
+	// Blocked сообщает, заблокировал ли user пользователя target.
+	Blocked(ctx context.Context, user, target uint64) (bool, error)
+	// ListBlocked возвращает заблокированных пользователем, последних - первыми.
+	ListBlocked(ctx context.Context, user uint64) ([]Block, error)
+	// List возвращает страницу связей пользователя.
+	List(ctx context.Context, user uint64, q Query) ([]Friendship, error)
+	// Friends возвращает друзей каждого из users, у каждого - не больше
+	// limit последних.
+	Friends(ctx context.Context, users []uint64, limit int) (map[uint64][]uint64, error)
+	// Related возвращает тех из candidates, с кем у user есть дружба,
+	// заявка в любую сторону или блокировка в любую сторону.
+	Related(ctx context.Context, user uint64, candidates []uint64) (map[uint64]bool, error)
+	// SentSince считает заявки, которые user отправил начиная с since,
+	// включая отозванные и отправленные заново.
+	SentSince(ctx context.Context, user uint64, since time.Time) (int, error)
+	// PruneSent удаляет из журнала квоты заявки, отправленные раньше before.
+	PruneSent(ctx context.Context, before time.Time) error
+	// ExpirePending переводит в Expired до limit заявок, отправленных
+	// не позже before, и возвращает, сколько их было.
+	ExpirePending(ctx context.Context, before, now time.Time, limit int) (int, error)
+
+	// CreateGroup сохраняет группу с участниками g.Members и назначает ей ID.
+	// Участники должны быть друзьями g.Owner, и их не больше maxMembers.
+	CreateGroup(ctx context.Context, g Group, maxMembers int) (Group, error)
+	// Groups возвращает группы owner, старые первыми.
+	Groups(ctx context.Context, owner uint64) ([]Group, error)
+	Group(ctx context.Context, owner, id uint64) (Group, error)
+	RenameGroup(ctx context.Context, owner, id uint64, name string, now time.Time) (Group, error)
+	DeleteGroup(ctx context.Context, owner, id uint64) error
+	// AddGroupMembers добавляет в группу друзей owner: все members или никого.
+	AddGroupMembers(ctx context.Context, owner, id uint64, members []uint64, maxMembers int, now time.Time) (Group, error)
+	RemoveGroupMember(ctx context.Context, owner, id, member uint64, now time.Time) (Group, error)
+}
+
+// Rules - ограничения Store.
+type Rules struct {
+	// SuggestScan - сколько последних друзей пользователя и каждого из них
+	// просматривает Suggest. От него зависит время поиска, а не от числа друзей.
+	SuggestScan int
+	// MutualScan - сколько последних друзей каждого пользователя сравнивает Mutual.
+	MutualScan int
+	// DailyRequests - сколько заявок пользователь может отправить за сутки; 0 - без ограничения.
+	DailyRequests int
+	// RejectionCooldown - сколько после отказа или отзыва нельзя отправить
+	// ту же заявку снова.
+	RejectionCooldown time.Duration
+	// RequestTTL - сколько заявка ждет ответа, прежде чем истечь.
+	RequestTTL time.Duration
+	// MaxGroups - сколько групп друзей может завести пользователь.
+	MaxGroups int
+	// MaxGroupMembers - сколько друзей помещается в одну группу.
+	MaxGroupMembers int
+}
+
+// Store применяет правила переходов к связям из Repository и публикует
+// в events каждое изменение, после того как оно сохранено.
+type Store struct {
+	repo   Repository
+	events events.Publisher
+	rules  Rules
+}
+
+func NewStore(repo Repository, bus events.Publisher, rules Rules) *Store {
+	return &Store{repo: repo, events: bus, rules: rules}
+}
+
+// publish сообщает об изменении, которое actor сделал со связью с target.
+// Изменение уже сохранено, поэтому ошибка шины только записывается в лог.
+func (s *Store) publish(ctx context.Context, kind events.Kind, actor, target uint64, at time.Time) {
+	err := s.events.Publish(ctx, events.Event{Kind: kind, Actor: actor, Target: target, At: at})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to publish relation event", "kind", kind, "error", err)
+	}
+}
+
+// quotaWindow - за какой срок Rules.DailyRequests ограничивает заявки.
+const quotaWindow = 24 * time.Hour
+
+// newRequest сообщает, что после изменения пары в ней новая заявка:
+// ее нужно записать в журнал квоты.
+func newRequest(was, f *Friendship) bool {
+	if f == nil || f.Status != Pending {
+		return false
+	}
+	return was == nil || was.Status != Pending || !was.CreatedAt.Equal(f.CreatedAt)
+}
+
+// expire переводит в Expired заявку, срок которой вышел, а sweeper
+// до нее еще не дошел, чтобы переходы не зависели от его расписания.
+func (s *Store) expire(f *Friendship, now time.Time) {
+	if f != nil && f.Status == Pending && !f.CreatedAt.Add(s.rules.RequestTTL).After(now) {
+		f.Status = Expired
+		f.UpdatedAt = now
+	}
+}
+
+// now округляет время до микросекунд, как его хранит PostgreSQL,
+// чтобы курсоры страниц не зависели от хранилища.
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// Send отправляет заявку from -> to. Встречная заявка, которую to уже
+// отправил from, при этом принимается. Если to заблокировал from, заявка
+// молча отбрасывается: from получает ответ как об отправленной заявке.
+// Новые заявки ограничены Rules.DailyRequests в сутки, а после отказа
+// или отзыва ту же заявку нельзя отправить Rules.RejectionCooldown.
+func (s *Store) Send(ctx context.Context, from, to uint64) (Friendship, error) {
+	if from == to {
+		return Friendship{}, ErrSelf
+	}
+
+	// квота проверяется до блокировки пары: параллельные заявки разным
+	// пользователям могут превысить ее на несколько штук
+	var sent int
+	if s.rules.DailyRequests > 0 {
+		var err error
+		sent, err = s.repo.SentSince(ctx, from, now().Add(-quotaWindow))
+		if err != nil {
+			return Friendship{}, err
+		}
+	}
+
+	var out Friendship
+	// kind остается нулевым для отброшенной заявки: событие выдало бы блокировку
+	var kind events.Kind
+	err := s.repo.Update(ctx, from, to, func(p *Pair) error {
+		now := now()
+		if _, ok := p.Blocks[from]; ok {
+			return ErrBlocked
+		}
+		if _, ok := p.Blocks[to]; ok {
+			out = Friendship{Requester: from, Addressee: to, Status: Pending, CreatedAt: now, UpdatedAt: now}
+			return nil
+		}
+
+		if f := p.Friendship; f != nil {
+			s.expire(f, now)
+			switch f.Status {
+			case Accepted:
+				return ErrAlreadyFriends
+			case Pending:
+				if f.Requester == from {
+					return ErrAlreadyRequested
+				}
+				f.Status = Accepted
+				f.UpdatedAt = now
+				out, kind = *f, events.RequestAccepted
+				return nil
+			case Rejected, Withdrawn:
+				if f.Requester == from && now.Sub(f.UpdatedAt) < s.rules.RejectionCooldown {
+					return ErrCooldown
+				}
+			}
+		}
+		if s.rules.DailyRequests > 0 && sent >= s.rules.DailyRequests {
+			return ErrQuota
+		}
+
+		// после отказа, отзыва, удаления или истечения заявка отправляется заново
+		p.Friendship = &Friendship{Requester: from, Addressee: to, Status: Pending, CreatedAt: now, UpdatedAt: now}
+		out, kind = *p.Friendship, events.RequestSent
+		return nil
+	})
+	if err == nil && kind != 0 {
+		s.publish(ctx, kind, from, to, out.UpdatedAt)
+	}
+	return out, err
+}
+
+// Respond принимает или отклоняет заявку, которую requester отправил addressee.
+func (s *Store) Respond(ctx context.Context, addressee, requester uint64, accept bool) (Friendship, error) {
+	var out Friendship
+	err := s.repo.Update(ctx, addressee, requester, func(p *Pair) error {
+		now := now()
+		f := p.Friendship
+		s.expire(f, now)
+		if f == nil || f.Status != Pending || f.Requester != requester {
+			return ErrNotFound
+		}
+
+		f.Status = Rejected
+		if accept {
+			f.Status = Accepted
+		}
+		f.UpdatedAt = now
+		out = *f
+		return nil
+	})
+	if err == nil {
+		kind := events.RequestRejected
+		if accept {
+			kind = events.RequestAccepted
+		}
+		s.publish(ctx, kind, addressee, requester, out.UpdatedAt)
+	}
+	return out, err
+}
+
+// Remove удаляет друга или отзывает заявку, которую user отправил other.
+func (s *Store) Remove(ctx context.Context, user, other uint64) (Friendship, error) {
+	var out Friendship
+	err := s.repo.Update(ctx, user, other, func(p *Pair) error {
+		now := now()
+		f := p.Friendship
+		s.expire(f, now)
+		switch {
+		case f == nil:
+			return ErrNotFound
+		case f.Status == Accepted:
+			f.Status = Removed
+		case f.Status == Pending && f.Requester == user:
+			f.Status = Withdrawn
+		default:
+			return ErrNotFound
+		}
+
+		f.UpdatedAt = now
+		out = *f
+		return nil
+	})
+	if err == nil {
+		s.publish(ctx, events.FriendshipRemoved, user, other, out.UpdatedAt)
+	}
+	return out, err
+}
+
+// Block блокирует target для user: их дружба и заявки удаляются,
+// новые заявки от target отбрасываются.
+func (s *Store) Block(ctx context.Context, user, target uint64) (time.Time, error) {
+	if user == target {
+		return time.Time{}, ErrSelf
+	}
+
+	var since time.Time
+	var blocked bool
+	err := s.repo.Update(ctx, user, target, func(p *Pair) error {
+		var ok bool
+		if since, ok = p.Blocks[user]; ok {
+			return nil
+		}
+		blocked = true
+
+		since = now()
+		if f := p.Friendship; f != nil && (f.Status == Pending || f.Status == Accepted) {
+			f.Status = Removed
+			f.UpdatedAt = since
+		}
+		p.Blocks[user] = since
+		return nil
+	})
+	if err == nil && blocked {
+		s.publish(ctx, events.UserBlocked, user, target, since)
+	}
+	return since, err
+}
+
+// Unblock снимает блокировку. Удаленная дружба не восстанавливается.
+func (s *Store) Unblock(ctx context.Context, user, target uint64) error {
+	err := s.repo.Update(ctx, user, target, func(p *Pair) error {
+		if _, ok := p.Blocks[user]; !ok {
+			return ErrNotBlocked
+		}
+		delete(p.Blocks, user)
+		return nil
+	})
+	if err == nil {
+		s.publish(ctx, events.UserUnblocked, user, target, now())
+	}
+	return err
+}
+
+// Blocked сообщает, заблокировал ли user пользователя target.
+func (s *Store) Blocked(ctx context.Context, user, target uint64) (bool, error) {
+	return s.repo.Blocked(ctx, user, target)
+}
+
+// RelationState - связь пары для проверок доступа.
+type RelationState int
+
+const (
+	RelationNone RelationState = iota
+	RelationPending
+	RelationFriends
+	// RelationBlocked - один из пары заблокировал другого.
+	RelationBlocked
+)
+
+// Relation - связь пользователя с другим.
+type Relation struct {
+	State RelationState
+	// Outgoing - пользователь отправил заявку (RelationPending) или
+	// заблокировал другого (RelationBlocked).
+	Outgoing bool
+}
+
+// Relation возвращает связь user с target. Блокировка в любую сторону
+// важнее дружбы и заявки; заявка старше RequestTTL считается истекшей,
+// даже если sweeper до нее еще не дошел.
+func (s *Store) Relation(ctx context.Context, user, target uint64) (Relation, error) {
+	p, err := s.repo.Pair(ctx, user, target)
+	if err != nil {
+		return Relation{}, err
+	}
+	if _, ok := p.Blocks[user]; ok {
+		return Relation{State: RelationBlocked, Outgoing: true}, nil
+	}
+	if _, ok := p.Blocks[target]; ok {
+		return Relation{State: RelationBlocked}, nil
+	}
+
+	f := p.Friendship
+	switch {
+	case f == nil:
+		return Relation{State: RelationNone}, nil
+	case f.Status == Accepted:
+		return Relation{State: RelationFriends}, nil
+	case f.Status == Pending && now().Sub(f.CreatedAt) < s.rules.RequestTTL:
+		return Relation{State: RelationPending, Outgoing: f.Requester == user}, nil
+	}
+	return Relation{State: RelationNone}, nil
+}
+
+// Block - заблокированный пользователь.
+type Block struct {
+	UserID    uint64
+	BlockedAt time.Time
+}
+
+// ListBlocked возвращает заблокированных пользователем, последних - первыми.
+func (s *Store) ListBlocked(ctx context.Context, user uint64) ([]Block, error) {
+	return s.repo.ListBlocked(ctx, user)
+}
+
+// Filter выбирает связи пользователя для List.
+type Filter int
+
+const (
+	// All - друзья и заявки в обе стороны.
+	All Filter = iota
+	Friends
+	Incoming
+	Outgoing
+)
+
+func (f Filter) match(user uint64, fr *Friendship) bool {
+	switch f {
+	case Friends:
+		return fr.Status == Accepted
+	case Incoming:
+		return fr.Status == Pending && fr.Addressee == user
+	case Outgoing:
+		return fr.Status == Pending && fr.Requester == user
+	}
+	return fr.Status == Pending || fr.Status == Accepted
+}
+
+// Cursor - позиция в списке: последняя связь предыдущей страницы.
+type Cursor struct {
+	UpdatedAt time.Time
+	UserID    uint64
+}
+
+// Query - страница списка связей. Связи упорядочены по UpdatedAt
+// и id второго пользователя.
+type Query struct {
+	Filter      Filter
+	OldestFirst bool
+	// After - связи после этой позиции; nil - с начала списка.
+	After *Cursor
+	Limit int
+}
+
+// List возвращает страницу связей пользователя.
+func (s *Store) List(ctx context.Context, user uint64, q Query) ([]Friendship, error) {
+	return s.repo.List(ctx, user, q)
+}
+
+// Cursor возвращает позицию связи в списке пользователя user.
+func (f Friendship) Cursor(user uint64) Cursor {
+	return Cursor{UpdatedAt: f.UpdatedAt, UserID: f.Other(user)}
+}
+
+// compare сравнивает положение связи f и позиции c в списке.
+func compare(user uint64, f Friendship, c Cursor, oldestFirst bool) int {
+	r := cmp.Or(f.UpdatedAt.Compare(c.UpdatedAt), cmp.Compare(f.Other(user), c.UserID))
+	if !oldestFirst {
+		r = -r
+	}
+	return r
+}
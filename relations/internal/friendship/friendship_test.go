@@ -0,0 +1,299 @@
+package friendship
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_messenger/relations/internal/events"
+)
+
+// op - действие пользователя user со связью с other.
+type op int
+
+const (
+	send op = iota + 1
+	accept
+	reject
+	remove
+	block
+	unblock
+)
+
+func (o op) do(ctx context.Context, s *Store, user, other uint64) error {
+	var err error
+	switch o {
+	case send:
+		_, err = s.Send(ctx, user, other)
+	case accept, reject:
+		_, err = s.Respond(ctx, user, other, o == accept)
+	case remove:
+		_, err = s.Remove(ctx, user, other)
+	case block:
+		_, err = s.Block(ctx, user, other)
+	case unblock:
+		err = s.Unblock(ctx, user, other)
+	}
+	return err
+}
+
+// befriend делает user другом каждого из others.
+func befriend(t *testing.T, s *Store, user uint64, others ...uint64) {
+	t.Helper()
+	for _, other := range others {
+		if _, err := s.Send(context.Background(), user, other); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Respond(context.Background(), other, user, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// step - шаг сценария и то, что после него хранится о паре.
+type step struct {
+	op          op
+	user, other uint64
+	err         error
+	// status и requester - связь пары после шага; 0 - связи нет
+	status    Status
+	requester uint64
+	// event - опубликованное событие; 0 - события нет
+	event events.Kind
+}
+
+var (
+	// rules для сценариев: заявки не истекают во время теста
+	testRules = Rules{RejectionCooldown: time.Hour, RequestTTL: time.Hour, DailyRequests: 10}
+
+	transitions = []struct {
+		name  string
+		steps []step
+	}{
+		{"accept", []step{
+			{op: send, user: 1, other: 2, status: Pending, requester: 1, event: events.RequestSent},
+			{op: accept, user: 2, other: 1, status: Accepted, requester: 1, event: events.RequestAccepted},
+		}},
+		{"reject", []step{
+			{op: send, user: 1, other: 2, status: Pending, requester: 1, event: events.RequestSent},
+			{op: reject, user: 2, other: 1, status: Rejected, requester: 1, event: events.RequestRejected},
+			{op: accept, user: 2, other: 1, err: ErrNotFound, status: Rejected, requester: 1},
+		}},
+		{"send to yourself", []step{
+			{op: send, user: 1, other: 1, err: ErrSelf},
+		}},
+		{"send twice", []step{
+			{op: send, user: 1, other: 2, status: Pending, requester: 1, event: events.RequestSent},
+			{op: send, user: 1, other: 2, err: ErrAlreadyRequested, status: Pending, requester: 1},
+		}},
+		{"mutual requests accept each other", []step{
+			{op: send, user: 1, other: 2, status: Pending, requester: 1, event: events.RequestSent},
+			{op: send, user: 2, other: 1, status: Accepted, requester: 1, event: events.RequestAccepted},
+			{op: send, user: 1, other: 2, err: ErrAlreadyFriends, status: Accepted, requester: 1},
+		}},
+		{"only the addressee responds", []step{
+			{op: accept, user: 2, other: 1, err: ErrNotFound},
+			{op: send, user: 1, other: 2, status: Pending, requester: 1, event: events.RequestSent},
+			{op: accept, user: 1, other: 2, err: ErrNotFound, status: Pending, requester: 1},
+			{op: reject, user: 3, other: 1, err: ErrNotFound},
+		}},
+		{"withdraw", []step{
+			{op: send, user: 1, other: 2, status: Pending, requester: 1, event: events.RequestSent},
+			{op: remove, user: 2, other: 1, err: ErrNotFound, status: Pending, requester: 1},
+			{op: remove, user: 1, other: 2, status: Withdrawn, requester: 1, event: events.FriendshipRemoved},
+			{op: accept, user: 2, other: 1, err: ErrNotFound, status: Withdrawn, requester: 1},
+			{op: send, user: 1, other: 2, err: ErrCooldown, status: Withdrawn, requester: 1},
+			{op: send, user: 2, other: 1, status: Pending, requester: 2, event: events.RequestSent},
+		}},
+		{"cooldown after rejection", []step{
+			{op: send, user: 1, other: 2, status: Pending, requester: 1, event: events.RequestSent},
+			{op: reject, user: 2, other: 1, status: Rejected, requester: 1, event: events.RequestRejected},
+			{op: send, user: 1, other: 2, err: ErrCooldown, status: Rejected, requester: 1},
+			{op: send, user: 2, other: 1, status: Pending, requester: 2, event: events.RequestSent},
+		}},
+		{"remove friend", []step{
+			{op: send, user: 1, other: 2, status: Pending, requester: 1, event: events.RequestSent},
+			{op: accept, user: 2, other: 1, status: Accepted, requester: 1, event: events.RequestAccepted},
+			{op: remove, user: 2, other: 1, status: Removed, requester: 1, event: events.FriendshipRemoved},
+			{op: remove, user: 1, other: 2, err: ErrNotFound, status: Removed, requester: 1},
+			{op: send, user: 1, other: 2, status: Pending, requester: 1, event: events.RequestSent},
+		}},
+		{"block removes friendship", []step{
+			{op: send, user: 1, other: 2, status: Pending, requester: 1, event: events.RequestSent},
+			{op: accept, user: 2, other: 1, status: Accepted, requester: 1, event: events.RequestAccepted},
+			{op: block, user: 1, other: 2, status: Removed, requester: 1, event: events.UserBlocked},
+			{op: block, user: 1, other: 2, status: Removed, requester: 1},
+			{op: send, user: 1, other: 2, err: ErrBlocked, status: Removed, requester: 1},
+		}},
+		{"block removes pending request", []step{
+			{op: send, user: 2, other: 1, status: Pending, requester: 2, event: events.RequestSent},
+			{op: block, user: 1, other: 2, status: Removed, requester: 2, event: events.UserBlocked},
+			{op: accept, user: 1, other: 2, err: ErrNotFound, status: Removed, requester: 2},
+		}},
+		{"request to a blocking user is dropped", []step{
+			{op: block, user: 1, other: 2, event: events.UserBlocked},
+			{op: send, user: 2, other: 1},
+			{op: accept, user: 1, other: 2, err: ErrNotFound},
+		}},
+		{"unblock", []step{
+			{op: unblock, user: 1, other: 2, err: ErrNotBlocked},
+			{op: block, user: 1, other: 2, event: events.UserBlocked},
+			{op: unblock, user: 2, other: 1, err: ErrNotBlocked},
+			{op: unblock, user: 1, other: 2, event: events.UserUnblocked},
+			{op: send, user: 1, other: 2, status: Pending, requester: 1, event: events.RequestSent},
+		}},
+		{"block yourself", []step{
+			{op: block, user: 1, other: 1, err: ErrSelf},
+		}},
+	}
+)
+
+// testTransitions прогоняет сценарии transitions, каждый на пустом
+// хранилище от newRepo.
+func testTransitions(t *testing.T, newRepo func(t *testing.T) Repository) {
+	for _, tt := range transitions {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+			bus := events.NewLocal(len(tt.steps))
+			sub, err := bus.Subscribe(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sub.Close()
+			s := NewStore(repo, bus, testRules)
+
+			for i, st := range tt.steps {
+				if err := st.op.do(ctx, s, st.user, st.other); !errors.Is(err, st.err) {
+					t.Fatalf("step %d: err = %v, want %v", i, err, st.err)
+				}
+
+				p, err := repo.Pair(ctx, st.user, st.other)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var status Status
+				var requester uint64
+				if f := p.Friendship; f != nil {
+					status, requester = f.Status, f.Requester
+				}
+				if status != st.status || requester != st.requester {
+					t.Fatalf("step %d: status %d from %d, want %d from %d", i, status, requester, st.status, st.requester)
+				}
+
+				var kind events.Kind
+				select {
+				case e := <-sub.Events():
+					kind = e.Kind
+				default:
+				}
+				if kind != st.event {
+					t.Fatalf("step %d: event %d, want %d", i, kind, st.event)
+				}
+			}
+		})
+	}
+}
+
+// testLimits проверяет квоту, отзыв заявок и их истечение.
+func testLimits(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+
+	t.Run("withdrawn requests count in the quota", func(t *testing.T) {
+		s := NewStore(newRepo(t), events.NewLocal(8), Rules{DailyRequests: 2, RequestTTL: time.Hour})
+		for _, to := range []uint64{2, 3} {
+			if _, err := s.Send(ctx, 1, to); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Remove(ctx, 1, to); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := s.Send(ctx, 1, 4); !errors.Is(err, ErrQuota) {
+			t.Fatalf("err = %v, want %v", err, ErrQuota)
+		}
+		// встречная заявка принимается и не упирается в квоту
+		if _, err := s.Send(ctx, 5, 1); err != nil {
+			t.Fatal(err)
+		}
+		if f, err := s.Send(ctx, 1, 5); err != nil || f.Status != Accepted {
+			t.Fatalf("Send = %v, %v, want accepted", f.Status, err)
+		}
+	})
+
+	t.Run("quota log is pruned", func(t *testing.T) {
+		repo := newRepo(t)
+		s := NewStore(repo, events.NewLocal(8), Rules{DailyRequests: 1, RequestTTL: time.Hour})
+		if _, err := s.Send(ctx, 1, 2); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := repo.SentSince(ctx, 1, time.Time{}); err != nil || n != 1 {
+			t.Fatalf("SentSince = %d, %v, want 1", n, err)
+		}
+		if err := repo.PruneSent(ctx, now().Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := repo.SentSince(ctx, 1, time.Time{}); err != nil || n != 0 {
+			t.Fatalf("SentSince after prune = %d, %v, want 0", n, err)
+		}
+	})
+
+	t.Run("overdue request cannot be answered before the sweep", func(t *testing.T) {
+		repo := newRepo(t)
+		s := NewStore(repo, events.NewLocal(8), Rules{RequestTTL: time.Millisecond})
+		if _, err := s.Send(ctx, 1, 2); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		if _, err := s.Respond(ctx, 2, 1, true); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Respond: err = %v, want %v", err, ErrNotFound)
+		}
+		if _, err := s.Remove(ctx, 1, 2); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Remove: err = %v, want %v", err, ErrNotFound)
+		}
+		// встречная заявка - новая заявка, а не согласие на истекшую
+		f, err := s.Send(ctx, 2, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Status != Pending || f.Requester != 2 {
+			t.Fatalf("Send = status %d from %d, want pending from 2", f.Status, f.Requester)
+		}
+	})
+
+	t.Run("sweep expires overdue requests", func(t *testing.T) {
+		repo := newRepo(t)
+		s := NewStore(repo, events.NewLocal(8), Rules{RequestTTL: time.Millisecond})
+		for _, to := range []uint64{2, 3} {
+			if _, err := s.Send(ctx, 1, to); err != nil {
+				t.Fatal(err)
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		n, err := s.ExpireRequests(ctx)
+		if err != nil || n != 2 {
+			t.Fatalf("ExpireRequests = %d, %v, want 2", n, err)
+		}
+		p, err := repo.Pair(ctx, 1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Friendship.Status != Expired {
+			t.Fatalf("status = %d, want %d", p.Friendship.Status, Expired)
+		}
+		if n, err := s.ExpireRequests(ctx); err != nil || n != 0 {
+			t.Fatalf("second ExpireRequests = %d, %v, want 0", n, err)
+		}
+	})
+}
+
+func TestMemory(t *testing.T) {
+	newRepo := func(*testing.T) Repository { return NewMemory() }
+	t.Run("transitions", func(t *testing.T) { testTransitions(t, newRepo) })
+	t.Run("limits", func(t *testing.T) { testLimits(t, newRepo) })
+	t.Run("groups", func(t *testing.T) { testGroups(t, newRepo) })
+	t.Run("suggestions", func(t *testing.T) { testSuggest(t, newRepo) })
+}
@@ -0,0 +1,117 @@
+package friendship
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const maxGroupName = 50
+
+var (
+	ErrGroupName     = errors.New("group name must be between 1 and 50 characters")
+	ErrGroupExists   = errors.New("group with this name already exists")
+	ErrGroupNotFound = errors.New("group not found")
+	ErrGroupLimit    = errors.New("friend group limit reached")
+	ErrMemberLimit   = errors.New("group member limit reached")
+	ErrNotFriend     = errors.New("only friends can be added to a group")
+	ErrNotMember     = errors.New("user is not in the group")
+)
+
+// Group - группа друзей, которую завел Owner, например "Семья".
+// Когда дружба заканчивается, Repository убирает друга из групп.
+type Group struct {
+	ID    uint64
+	Owner uint64
+	Name  string
+	// Members - друзья в группе по возрастанию id.
+	Members   []uint64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// groupName убирает пробелы по краям и проверяет длину имени.
+func groupName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if n := utf8.RuneCountInString(name); n == 0 || n > maxGroupName {
+		return "", ErrGroupName
+	}
+	return name, nil
+}
+
+// CreateGroup создает группу owner с друзьями members, если все они друзья.
+// Групп у пользователя не больше Rules.MaxGroups; как и квота заявок, лимит
+// проверяется до создания, и параллельные вызовы могут превысить его
+// на несколько групп.
+func (s *Store) CreateGroup(ctx context.Context, owner uint64, name string, members []uint64) (Group, error) {
+	name, err := groupName(name)
+	if err != nil {
+		return Group{}, err
+	}
+	groups, err := s.repo.Groups(ctx, owner)
+	if err != nil {
+		return Group{}, err
+	}
+	if len(groups) >= s.rules.MaxGroups {
+		return Group{}, ErrGroupLimit
+	}
+
+	members, err = friendList(owner, members)
+	if err != nil {
+		return Group{}, err
+	}
+	now := now()
+	g := Group{Owner: owner, Name: name, Members: members, CreatedAt: now, UpdatedAt: now}
+	return s.repo.CreateGroup(ctx, g, s.rules.MaxGroupMembers)
+}
+
+// friendList упорядочивает id по возрастанию без повторов: в этом порядке
+// Postgres блокирует пары, и параллельные вызовы не ждут друг друга по кругу.
+func friendList(owner uint64, members []uint64) ([]uint64, error) {
+	members = slices.Clone(members)
+	slices.Sort(members)
+	members = slices.Compact(members)
+	if slices.Contains(members, owner) {
+		return nil, ErrNotFriend
+	}
+	return members, nil
+}
+
+// Groups возвращает группы owner, старые первыми.
+func (s *Store) Groups(ctx context.Context, owner uint64) ([]Group, error) {
+	return s.repo.Groups(ctx, owner)
+}
+
+// Group возвращает группу owner.
+func (s *Store) Group(ctx context.Context, owner, id uint64) (Group, error) {
+	return s.repo.Group(ctx, owner, id)
+}
+
+func (s *Store) RenameGroup(ctx context.Context, owner, id uint64, name string) (Group, error) {
+	name, err := groupName(name)
+	if err != nil {
+		return Group{}, err
+	}
+	return s.repo.RenameGroup(ctx, owner, id, name, now())
+}
+
+func (s *Store) DeleteGroup(ctx context.Context, owner, id uint64) error {
+	return s.repo.DeleteGroup(ctx, owner, id)
+}
+
+// AddGroupMembers добавляет в группу друзей owner. Если хоть один
+// из members не друг, группа не меняется.
+func (s *Store) AddGroupMembers(ctx context.Context, owner, id uint64, members []uint64) (Group, error) {
+	members, err := friendList(owner, members)
+	if err != nil {
+		return Group{}, err
+	}
+	return s.repo.AddGroupMembers(ctx, owner, id, members, s.rules.MaxGroupMembers, now())
+}
+
+func (s *Store) RemoveGroupMember(ctx context.Context, owner, id, member uint64) (Group, error) {
+	return s.repo.RemoveGroupMember(ctx, owner, id, member, now())
+}
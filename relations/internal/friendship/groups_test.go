@@ -0,0 +1,106 @@
+package friendship
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_messenger/relations/internal/events"
+)
+
+// testGroups проверяет, что в группах бывают только друзья владельца.
+func testGroups(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+	rules := Rules{DailyRequests: 10, RequestTTL: time.Hour, MaxGroups: 5, MaxGroupMembers: 2}
+	members := func(t *testing.T, s *Store, owner, id uint64) []uint64 {
+		t.Helper()
+		g, err := s.Group(ctx, owner, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g.Members
+	}
+
+	t.Run("only accepted friends", func(t *testing.T) {
+		s := NewStore(newRepo(t), events.NewLocal(16), rules)
+		befriend(t, s, 1, 2)
+		// заявка 1 к 3 еще не принята, 4 с 1 вовсе не связан
+		if _, err := s.Send(ctx, 1, 3); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, others := range [][]uint64{{2, 3}, {4}, {1}} {
+			if _, err := s.CreateGroup(ctx, 1, "Family", others); !errors.Is(err, ErrNotFriend) {
+				t.Errorf("CreateGroup(%v): err = %v, want %v", others, err, ErrNotFriend)
+			}
+		}
+		g, err := s.CreateGroup(ctx, 1, "Family", []uint64{2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.AddGroupMembers(ctx, 1, g.ID, []uint64{3}); !errors.Is(err, ErrNotFriend) {
+			t.Fatalf("AddGroupMembers: err = %v, want %v", err, ErrNotFriend)
+		}
+
+		if _, err := s.Respond(ctx, 3, 1, true); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.AddGroupMembers(ctx, 1, g.ID, []uint64{3}); err != nil {
+			t.Fatal(err)
+		}
+		if got := members(t, s, 1, g.ID); !slices.Equal(got, []uint64{2, 3}) {
+			t.Errorf("members = %v, want [2 3]", got)
+		}
+	})
+
+	t.Run("ended friendship leaves groups", func(t *testing.T) {
+		s := NewStore(newRepo(t), events.NewLocal(16), rules)
+		befriend(t, s, 1, 2, 3)
+		befriend(t, s, 2, 3)
+		mine, err := s.CreateGroup(ctx, 1, "Family", []uint64{2, 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		theirs, err := s.CreateGroup(ctx, 3, "Work", []uint64{1, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := s.Remove(ctx, 2, 1); err != nil {
+			t.Fatal(err)
+		}
+		if got := members(t, s, 1, mine.ID); !slices.Equal(got, []uint64{3}) {
+			t.Errorf("after removal: members = %v, want [3]", got)
+		}
+		// блокировка заканчивает дружбу в обе стороны
+		if _, err := s.Block(ctx, 1, 3); err != nil {
+			t.Fatal(err)
+		}
+		if got := members(t, s, 1, mine.ID); len(got) != 0 {
+			t.Errorf("after block: owner's group members = %v, want none", got)
+		}
+		if got := members(t, s, 3, theirs.ID); !slices.Equal(got, []uint64{2}) {
+			t.Errorf("after block: blocked user's group members = %v, want [2]", got)
+		}
+	})
+
+	t.Run("repeated ids do not count toward the limit", func(t *testing.T) {
+		repo := newRepo(t)
+		s := NewStore(repo, events.NewLocal(16), rules)
+		befriend(t, s, 1, 2, 3)
+
+		now := time.Now()
+		g, err := repo.CreateGroup(ctx, Group{Owner: 1, Name: "Family", Members: []uint64{2, 2, 3, 3}, CreatedAt: now, UpdatedAt: now}, 2)
+		if err != nil {
+			t.Fatalf("CreateGroup: %v", err)
+		}
+		if got := members(t, s, 1, g.ID); !slices.Equal(got, []uint64{2, 3}) {
+			t.Errorf("members = %v, want [2 3]", got)
+		}
+		if _, err := s.CreateGroup(ctx, 1, "Friends", []uint64{2, 3, 2}); err != nil {
+			t.Errorf("Store.CreateGroup: %v", err)
+		}
+	})
+}
@@ -0,0 +1,418 @@
+package friendship
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pair - ключ связи, не зависящий от того, кто отправил заявку.
+type pair struct{ low, high uint64 }
+
+func key(a, b uint64) pair {
+	if a > b {
+		a, b = b, a
+	}
+	return pair{a, b}
+}
+
+// Memory хранит по одной связи на пару пользователей в памяти процесса.
+type Memory struct {
+	mx    sync.RWMutex
+	pairs map[pair]*Friendship
+	// byUser - связи каждого пользователя, чтобы не перебирать все пары
+	byUser map[uint64]map[pair]*Friendship
+	// blocks - кого заблокировал пользователь и когда
+	blocks map[uint64]map[uint64]time.Time
+	// sent - когда пользователь отправлял заявки, старые первыми
+	sent map[uint64][]time.Time
+
+	lastGroupID uint64
+	// groups - группы каждого пользователя по id
+	groups map[uint64]map[uint64]*memoryGroup
+}
+
+// memoryGroup - Group, участники которой хранятся множеством.
+type memoryGroup struct {
+	Group
+	members map[uint64]struct{}
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		pairs:  make(map[pair]*Friendship),
+		byUser: make(map[uint64]map[pair]*Friendship),
+		blocks: make(map[uint64]map[uint64]time.Time),
+		sent:   make(map[uint64][]time.Time),
+		groups: make(map[uint64]map[uint64]*memoryGroup),
+	}
+}
+
+func (m *Memory) Update(_ context.Context, a, b uint64, fn func(p *Pair) error) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	// fn меняет копию, чтобы после ошибки пара осталась прежней
+	p := Pair{Blocks: make(map[uint64]time.Time)}
+	was := m.pairs[key(a, b)]
+	friends := false
+	if was != nil {
+		f := *was
+		p.Friendship = &f
+		friends = f.Status == Accepted
+	}
+	for user, target := range map[uint64]uint64{a: b, b: a} {
+		if since, ok := m.blocks[user][target]; ok {
+			p.Blocks[user] = since
+		}
+	}
+
+	if err := fn(&p); err != nil {
+		return err
+	}
+
+	if f := p.Friendship; newRequest(was, f) {
+		m.sent[f.Requester] = append(m.sent[f.Requester], f.CreatedAt)
+	}
+	if p.Friendship != nil {
+		m.put(p.Friendship)
+		if friends && p.Friendship.Status != Accepted {
+			m.leaveGroupsLocked(a, b)
+			m.leaveGroupsLocked(b, a)
+		}
+	}
+	for user, target := range map[uint64]uint64{a: b, b: a} {
+		since, ok := p.Blocks[user]
+		switch {
+		case ok && m.blocks[user] == nil:
+			m.blocks[user] = map[uint64]time.Time{target: since}
+		case ok:
+			m.blocks[user][target] = since
+		default:
+			delete(m.blocks[user], target)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Pair(_ context.Context, a, b uint64) (Pair, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	p := Pair{Blocks: make(map[uint64]time.Time)}
+	if f, ok := m.pairs[key(a, b)]; ok {
+		f := *f
+		p.Friendship = &f
+	}
+	for user, target := range map[uint64]uint64{a: b, b: a} {
+		if since, ok := m.blocks[user][target]; ok {
+			p.Blocks[user] = since
+		}
+	}
+	return p, nil
+}
+
+func (m *Memory) Blocked(_ context.Context, user, target uint64) (bool, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	_, ok := m.blocks[user][target]
+	return ok, nil
+}
+
+func (m *Memory) ListBlocked(_ context.Context, user uint64) ([]Block, error) {
+	m.mx.RLock()
+	out := make([]Block, 0, len(m.blocks[user]))
+	for target, at := range m.blocks[user] {
+		out = append(out, Block{UserID: target, BlockedAt: at})
+	}
+	m.mx.RUnlock()
+
+	slices.SortFunc(out, func(a, b Block) int {
+		return cmp.Or(b.BlockedAt.Compare(a.BlockedAt), cmp.Compare(a.UserID, b.UserID))
+	})
+	return out, nil
+}
+
+func (m *Memory) List(_ context.Context, user uint64, q Query) ([]Friendship, error) {
+	m.mx.RLock()
+	var out []Friendship
+	for _, f := range m.byUser[user] {
+		if !q.Filter.match(user, f) {
+			continue
+		}
+		if q.After != nil && compare(user, *f, *q.After, q.OldestFirst) <= 0 {
+			continue
+		}
+		out = append(out, *f)
+	}
+	m.mx.RUnlock()
+
+	slices.SortFunc(out, func(a, b Friendship) int {
+		return compare(user, a, b.Cursor(user), q.OldestFirst)
+	})
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+	return out, nil
+}
+
+func (m *Memory) Friends(_ context.Context, users []uint64, limit int) (map[uint64][]uint64, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	out := make(map[uint64][]uint64, len(users))
+	for _, user := range users {
+		var friends []*Friendship
+		for _, f := range m.byUser[user] {
+			if f.Status == Accepted {
+				friends = append(friends, f)
+			}
+		}
+		slices.SortFunc(friends, func(a, b *Friendship) int {
+			return compare(user, *a, b.Cursor(user), false)
+		})
+
+		ids := make([]uint64, 0, min(limit, len(friends)))
+		for _, f := range friends[:min(limit, len(friends))] {
+			ids = append(ids, f.Other(user))
+		}
+		out[user] = ids
+	}
+	return out, nil
+}
+
+func (m *Memory) Related(_ context.Context, user uint64, candidates []uint64) (map[uint64]bool, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	out := make(map[uint64]bool)
+	for _, c := range candidates {
+		if f, ok := m.pairs[key(user, c)]; ok && (f.Status == Pending || f.Status == Accepted) {
+			out[c] = true
+		}
+		_, blocked := m.blocks[user][c]
+		_, blockedBy := m.blocks[c][user]
+		if blocked || blockedBy {
+			out[c] = true
+		}
+	}
+	return out, nil
+}
+
+func (m *Memory) SentSince(_ context.Context, user uint64, since time.Time) (int, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	n := 0
+	for _, at := range m.sent[user] {
+		if !at.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *Memory) PruneSent(_ context.Context, before time.Time) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	for user, times := range m.sent {
+		i, _ := slices.BinarySearchFunc(times, before, time.Time.Compare)
+		if i == len(times) {
+			delete(m.sent, user)
+		} else {
+			m.sent[user] = slices.Delete(times, 0, i)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) ExpirePending(_ context.Context, before, now time.Time, limit int) (int, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	n := 0
+	for _, f := range m.pairs {
+		if n == limit {
+			break
+		}
+		if f.Status == Pending && !f.CreatedAt.After(before) {
+			f.Status = Expired
+			f.UpdatedAt = now
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *Memory) put(f *Friendship) {
+	k := key(f.Requester, f.Addressee)
+	m.pairs[k] = f
+	for _, user := range []uint64{f.Requester, f.Addressee} {
+		if m.byUser[user] == nil {
+			m.byUser[user] = make(map[pair]*Friendship)
+		}
+		m.byUser[user][k] = f
+	}
+}
+
+// leaveGroupsLocked убирает member из групп owner.
+func (m *Memory) leaveGroupsLocked(owner, member uint64) {
+	for _, g := range m.groups[owner] {
+		delete(g.members, member)
+	}
+}
+
+// friendsLocked проверяет, что все members - друзья owner.
+func (m *Memory) friendsLocked(owner uint64, members []uint64) error {
+	for _, member := range members {
+		if f, ok := m.pairs[key(owner, member)]; !ok || f.Status != Accepted {
+			return ErrNotFriend
+		}
+	}
+	return nil
+}
+
+func (m *Memory) CreateGroup(_ context.Context, g Group, maxMembers int) (Group, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	for _, other := range m.groups[g.Owner] {
+		if strings.EqualFold(other.Name, g.Name) {
+			return Group{}, ErrGroupExists
+		}
+	}
+	if err := m.friendsLocked(g.Owner, g.Members); err != nil {
+		return Group{}, err
+	}
+	// повторы одного друга не занимают места в группе, как и в Postgres
+	mg := &memoryGroup{Group: g, members: make(map[uint64]struct{}, len(g.Members))}
+	for _, member := range g.Members {
+		mg.members[member] = struct{}{}
+	}
+	if len(mg.members) > maxMembers {
+		return Group{}, ErrMemberLimit
+	}
+
+	m.lastGroupID++
+	mg.ID = m.lastGroupID
+	mg.Members = nil
+	if m.groups[g.Owner] == nil {
+		m.groups[g.Owner] = make(map[uint64]*memoryGroup)
+	}
+	m.groups[g.Owner][mg.ID] = mg
+	return mg.group(), nil
+}
+
+func (m *Memory) Groups(_ context.Context, owner uint64) ([]Group, error) {
+	m.mx.RLock()
+	out := make([]Group, 0, len(m.groups[owner]))
+	for _, g := range m.groups[owner] {
+		out = append(out, g.group())
+	}
+	m.mx.RUnlock()
+
+	slices.SortFunc(out, func(a, b Group) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return out, nil
+}
+
+func (m *Memory) Group(_ context.Context, owner, id uint64) (Group, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	g, ok := m.groups[owner][id]
+	if !ok {
+		return Group{}, ErrGroupNotFound
+	}
+	return g.group(), nil
+}
+
+func (m *Memory) RenameGroup(_ context.Context, owner, id uint64, name string, now time.Time) (Group, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	g, ok := m.groups[owner][id]
+	if !ok {
+		return Group{}, ErrGroupNotFound
+	}
+	for _, other := range m.groups[owner] {
+		if other.ID != id && strings.EqualFold(other.Name, name) {
+			return Group{}, ErrGroupExists
+		}
+	}
+	g.Name = name
+	g.UpdatedAt = now
+	return g.group(), nil
+}
+
+func (m *Memory) DeleteGroup(_ context.Context, owner, id uint64) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	if _, ok := m.groups[owner][id]; !ok {
+		return ErrGroupNotFound
+	}
+	delete(m.groups[owner], id)
+	return nil
+}
+
+func (m *Memory) AddGroupMembers(_ context.Context, owner, id uint64, members []uint64, maxMembers int, now time.Time) (Group, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	g, ok := m.groups[owner][id]
+	if !ok {
+		return Group{}, ErrGroupNotFound
+	}
+	if err := m.friendsLocked(owner, members); err != nil {
+		return Group{}, err
+	}
+	n := len(g.members)
+	for _, member := range members {
+		if _, ok := g.members[member]; !ok {
+			n++
+		}
+	}
+	if n > maxMembers {
+		return Group{}, ErrMemberLimit
+	}
+
+	for _, member := range members {
+		g.members[member] = struct{}{}
+	}
+	g.UpdatedAt = now
+	return g.group(), nil
+}
+
+func (m *Memory) RemoveGroupMember(_ context.Context, owner, id, member uint64, now time.Time) (Group, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	g, ok := m.groups[owner][id]
+	if !ok {
+		return Group{}, ErrGroupNotFound
+	}
+	if _, ok := g.members[member]; !ok {
+		return Group{}, ErrNotMember
+	}
+	delete(g.members, member)
+	g.UpdatedAt = now
+	return g.group(), nil
+}
+
+// group копирует группу вместе с участниками.
+func (g *memoryGroup) group() Group {
+	out := g.Group
+	out.Members = make([]uint64, 0, len(g.members))
+	for member := range g.members {
+		out.Members = append(out.Members, member)
+	}
+	slices.Sort(out.Members)
+	return out
+}
@@ -0,0 +1,18 @@
+-- Одна строка на пару пользователей: user_low < user_high, кто отправил
+-- заявку - в requester. status - значения friendship.Status.
+-- Id - публичные uint64 id пользователей с тем же набором бит в BIGINT.
+CREATE TABLE friendships (
+    user_low   BIGINT      NOT NULL,
+    user_high  BIGINT      NOT NULL,
+    requester  BIGINT      NOT NULL,
+    status     SMALLINT    NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_low, user_high),
+    CHECK (user_low < user_high),
+    CHECK (requester IN (user_low, user_high))
+);
+
+-- списки связей пользователя по updated_at с обеих сторон пары
+CREATE INDEX friendships_low_updated_idx ON friendships (user_low, updated_at, user_high);
+CREATE INDEX friendships_high_updated_idx ON friendships (user_high, updated_at, user_low);
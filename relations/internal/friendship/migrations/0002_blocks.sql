@@ -0,0 +1,10 @@
+-- user_id заблокировал target_id.
+CREATE TABLE blocks (
+    user_id    BIGINT      NOT NULL,
+    target_id  BIGINT      NOT NULL,
+    blocked_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, target_id),
+    CHECK (user_id <> target_id)
+);
+
+CREATE INDEX blocks_user_blocked_at_idx ON blocks (user_id, blocked_at DESC, target_id);
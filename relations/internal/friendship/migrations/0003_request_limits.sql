@@ -0,0 +1,5 @@
+-- квота: сколько заявок пользователь отправил за сутки
+CREATE INDEX friendships_requester_created_idx ON friendships (requester, created_at);
+
+-- заявки, ждущие ответа, для истечения по request_ttl
+CREATE INDEX friendships_pending_created_idx ON friendships (created_at) WHERE status = 1;
@@ -0,0 +1,21 @@
+-- группы друзей, которые завел owner_id
+CREATE TABLE friend_groups (
+    id         BIGSERIAL   PRIMARY KEY,
+    owner_id   BIGINT      NOT NULL,
+    name       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- имена групп одного пользователя не повторяются без учета регистра
+CREATE UNIQUE INDEX friend_groups_owner_name_idx ON friend_groups (owner_id, lower(name));
+
+CREATE TABLE friend_group_members (
+    group_id  BIGINT      NOT NULL REFERENCES friend_groups (id) ON DELETE CASCADE,
+    member_id BIGINT      NOT NULL,
+    added_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (group_id, member_id)
+);
+
+-- удаление из групп, когда дружба заканчивается
+CREATE INDEX friend_group_members_member_idx ON friend_group_members (member_id);
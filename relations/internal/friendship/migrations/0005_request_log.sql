@@ -0,0 +1,20 @@
+-- журнал отправленных заявок для квоты: отзыв и повторная отправка
+-- перезаписывают строку friendships, но не возвращают место в квоте.
+-- Записи старше суток удаляет sweeper.
+CREATE TABLE friend_request_log (
+    requester BIGINT      NOT NULL,
+    addressee BIGINT      NOT NULL,
+    sent_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX friend_request_log_requester_sent_idx ON friend_request_log (requester, sent_at);
+CREATE INDEX friend_request_log_sent_idx ON friend_request_log (sent_at);
+
+-- заявки последних суток уже идут в квоту
+INSERT INTO friend_request_log (requester, addressee, sent_at)
+SELECT requester, CASE WHEN requester = user_low THEN user_high ELSE user_low END, created_at
+FROM friendships
+WHERE created_at >= now() - interval '1 day';
+
+-- квоту теперь считает журнал
+DROP INDEX friendships_requester_created_idx;
@@ -0,0 +1,645 @@
+package friendship
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres хранит связи и блокировки в PostgreSQL, схема - в migrations.
+// Id пользователей - uint64, в BIGINT они лежат с тем же набором бит,
+// поэтому порядок id в базе - порядок int64.
+type Postgres struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgres(pool *pgxpool.Pool) *Postgres {
+	return &Postgres{pool: pool}
+}
+
+// rowKey возвращает ключ строки friendships для пары a, b.
+func rowKey(a, b uint64) (low, high int64) {
+	low, high = int64(a), int64(b)
+	if low > high {
+		low, high = high, low
+	}
+	return low, high
+}
+
+// lockKey - ключ advisory lock пары: Update одной пары на разных
+// репликах выполняются по очереди, даже если строки пары еще нет.
+func lockKey(low, high int64) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(strconv.FormatInt(low, 10) + ":" + strconv.FormatInt(high, 10)))
+	return int64(h.Sum64())
+}
+
+func (p *Postgres) Update(ctx context.Context, a, b uint64, fn func(p *Pair) error) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	low, high := rowKey(a, b)
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, lockKey(low, high)); err != nil {
+		return fmt.Errorf("lock pair: %w", err)
+	}
+
+	// строку пары блокирует и FOR UPDATE: advisory lock видят только
+	// другие Update, а ExpirePending меняет строки без него
+	pair, err := loadPair(ctx, tx, low, high, " FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	before := make(map[uint64]time.Time, len(pair.Blocks))
+	for user, since := range pair.Blocks {
+		before[user] = since
+	}
+	var was *Friendship
+	if pair.Friendship != nil {
+		f := *pair.Friendship
+		was = &f
+	}
+	friends := was != nil && was.Status == Accepted
+
+	if err := fn(&pair); err != nil {
+		return err
+	}
+
+	if f := pair.Friendship; f != nil {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO friendships (user_low, user_high, requester, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (user_low, user_high) DO UPDATE
+			SET requester = excluded.requester, status = excluded.status,
+			    created_at = excluded.created_at, updated_at = excluded.updated_at`,
+			low, high, int64(f.Requester), f.Status, f.CreatedAt, f.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("save friendship: %w", err)
+		}
+		if friends && f.Status != Accepted {
+			if _, err := tx.Exec(ctx, leaveGroupsSQL, low, high); err != nil {
+				return fmt.Errorf("leave groups: %w", err)
+			}
+		}
+		if newRequest(was, f) {
+			_, err := tx.Exec(ctx, `INSERT INTO friend_request_log (requester, addressee, sent_at) VALUES ($1, $2, $3)`,
+				int64(f.Requester), int64(f.Addressee), f.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("log friend request: %w", err)
+			}
+		}
+	}
+
+	for user, target := range map[uint64]uint64{a: b, b: a} {
+		since, now := pair.Blocks[user]
+		_, was := before[user]
+		switch {
+		case now && !was:
+			_, err = tx.Exec(ctx, `INSERT INTO blocks (user_id, target_id, blocked_at) VALUES ($1, $2, $3)`,
+				int64(user), int64(target), since)
+		case was && !now:
+			_, err = tx.Exec(ctx, `DELETE FROM blocks WHERE user_id = $1 AND target_id = $2`,
+				int64(user), int64(target))
+		}
+		if err != nil {
+			return fmt.Errorf("save block: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// Pair читает пару без блокировки: для проверок, которым не нужно
+// ее менять.
+func (p *Postgres) Pair(ctx context.Context, a, b uint64) (Pair, error) {
+	low, high := rowKey(a, b)
+	return loadPair(ctx, p.pool, low, high, "")
+}
+
+// loadPair читает связь и блокировки пары low < high; suffix дописывается
+// к выборке связи, например FOR UPDATE.
+func loadPair(ctx context.Context, q queryer, low, high int64, suffix string) (Pair, error) {
+	pair := Pair{Blocks: make(map[uint64]time.Time)}
+	f := Friendship{}
+	var requester int64
+	err := q.QueryRow(ctx, `
+		SELECT requester, status, created_at, updated_at
+		FROM friendships
+		WHERE user_low = $1 AND user_high = $2`+suffix, low, high,
+	).Scan(&requester, &f.Status, &f.CreatedAt, &f.UpdatedAt)
+	switch {
+	case err == nil:
+		f.Requester = uint64(requester)
+		f.Addressee = uint64(low)
+		if requester == low {
+			f.Addressee = uint64(high)
+		}
+		pair.Friendship = &f
+	case !errors.Is(err, pgx.ErrNoRows):
+		return Pair{}, fmt.Errorf("select friendship: %w", err)
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT user_id, blocked_at
+		FROM blocks
+		WHERE (user_id = $1 AND target_id = $2) OR (user_id = $2 AND target_id = $1)`, low, high)
+	if err != nil {
+		return Pair{}, fmt.Errorf("select blocks: %w", err)
+	}
+	var user int64
+	var since time.Time
+	_, err = pgx.ForEachRow(rows, []any{&user, &since}, func() error {
+		pair.Blocks[uint64(user)] = since
+		return nil
+	})
+	if err != nil {
+		return Pair{}, fmt.Errorf("select blocks: %w", err)
+	}
+	return pair, nil
+}
+
+func (p *Postgres) Blocked(ctx context.Context, user, target uint64) (bool, error) {
+	var blocked bool
+	err := p.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM blocks WHERE user_id = $1 AND target_id = $2)`,
+		int64(user), int64(target),
+	).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf("select block: %w", err)
+	}
+	return blocked, nil
+}
+
+func (p *Postgres) ListBlocked(ctx context.Context, user uint64) ([]Block, error) {
+	rows, err := p.pool.Query(ctx, `
+		SELECT target_id, blocked_at
+		FROM blocks
+		WHERE user_id = $1
+		ORDER BY blocked_at DESC, target_id`, int64(user))
+	if err != nil {
+		return nil, fmt.Errorf("select blocks: %w", err)
+	}
+
+	out := []Block{}
+	var b Block
+	var target int64
+	_, err = pgx.ForEachRow(rows, []any{&target, &b.BlockedAt}, func() error {
+		b.UserID = uint64(target)
+		out = append(out, b)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select blocks: %w", err)
+	}
+	return out, nil
+}
+
+// filterSQL - условия Filter для связей пользователя $1.
+var filterSQL = map[Filter]string{
+	All:      fmt.Sprintf("status IN (%d, %d)", Pending, Accepted),
+	Friends:  fmt.Sprintf("status = %d", Accepted),
+	Incoming: fmt.Sprintf("status = %d AND requester <> $1", Pending),
+	Outgoing: fmt.Sprintf("status = %d AND requester = $1", Pending),
+}
+
+func (p *Postgres) List(ctx context.Context, user uint64, q Query) ([]Friendship, error) {
+	filter, ok := filterSQL[q.Filter]
+	if !ok {
+		return nil, fmt.Errorf("unknown filter %d", q.Filter)
+	}
+
+	// связи пользователя лежат по обе стороны пары, у каждой стороны свой
+	// индекс, поэтому две выборки объединяются, а не ищутся через OR
+	var sql strings.Builder
+	sql.WriteString(`
+		SELECT other, requester, status, created_at, updated_at
+		FROM (
+			SELECT user_high AS other, requester, status, created_at, updated_at
+			FROM friendships WHERE user_low = $1
+			UNION ALL
+			SELECT user_low, requester, status, created_at, updated_at
+			FROM friendships WHERE user_high = $1
+		) f
+		WHERE ` + filter)
+
+	order, after := "ASC", ">"
+	if !q.OldestFirst {
+		order, after = "DESC", "<"
+	}
+	args := []any{int64(user)}
+	if q.After != nil {
+		args = append(args, q.After.UpdatedAt, int64(q.After.UserID))
+		sql.WriteString(" AND (updated_at, other) " + after + " ($2, $3)")
+	}
+	sql.WriteString(" ORDER BY updated_at " + order + ", other " + order)
+	if q.Limit > 0 {
+		args = append(args, q.Limit)
+		sql.WriteString(" LIMIT $" + strconv.Itoa(len(args)))
+	}
+
+	rows, err := p.pool.Query(ctx, sql.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("select friendships: %w", err)
+	}
+
+	var out []Friendship
+	var f Friendship
+	var other, requester int64
+	_, err = pgx.ForEachRow(rows, []any{&other, &requester, &f.Status, &f.CreatedAt, &f.UpdatedAt}, func() error {
+		f.Requester, f.Addressee = user, uint64(other)
+		if uint64(requester) != user {
+			f.Requester, f.Addressee = uint64(other), user
+		}
+		out = append(out, f)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select friendships: %w", err)
+	}
+	return out, nil
+}
+
+// friendsSQL берет у каждого пользователя из $1 до $2 последних друзей:
+// LATERAL читает индексы по updated_at и останавливается на LIMIT.
+var friendsSQL = fmt.Sprintf(`
+	SELECT u.id, f.other
+	FROM unnest($1::bigint[]) AS u(id)
+	CROSS JOIN LATERAL (
+		SELECT other FROM (
+			SELECT user_high AS other, updated_at
+			FROM friendships WHERE user_low = u.id AND status = %[1]d
+			UNION ALL
+			SELECT user_low, updated_at
+			FROM friendships WHERE user_high = u.id AND status = %[1]d
+		) s
+		ORDER BY updated_at DESC, other DESC
+		LIMIT $2
+	) f`, Accepted)
+
+func (p *Postgres) Friends(ctx context.Context, users []uint64, limit int) (map[uint64][]uint64, error) {
+	rows, err := p.pool.Query(ctx, friendsSQL, ids(users), limit)
+	if err != nil {
+		return nil, fmt.Errorf("select friends: %w", err)
+	}
+
+	out := make(map[uint64][]uint64, len(users))
+	var user, friend int64
+	_, err = pgx.ForEachRow(rows, []any{&user, &friend}, func() error {
+		out[uint64(user)] = append(out[uint64(user)], uint64(friend))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select friends: %w", err)
+	}
+	return out, nil
+}
+
+var relatedSQL = fmt.Sprintf(`
+	SELECT user_high FROM friendships
+	WHERE user_low = $1 AND user_high = ANY($2) AND status IN (%[1]d, %[2]d)
+	UNION
+	SELECT user_low FROM friendships
+	WHERE user_high = $1 AND user_low = ANY($2) AND status IN (%[1]d, %[2]d)
+	UNION
+	SELECT target_id FROM blocks WHERE user_id = $1 AND target_id = ANY($2)
+	UNION
+	SELECT user_id FROM blocks WHERE user_id = ANY($2) AND target_id = $1`, Pending, Accepted)
+
+func (p *Postgres) Related(ctx context.Context, user uint64, candidates []uint64) (map[uint64]bool, error) {
+	rows, err := p.pool.Query(ctx, relatedSQL, int64(user), ids(candidates))
+	if err != nil {
+		return nil, fmt.Errorf("select related: %w", err)
+	}
+
+	out := make(map[uint64]bool)
+	var id int64
+	_, err = pgx.ForEachRow(rows, []any{&id}, func() error {
+		out[uint64(id)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select related: %w", err)
+	}
+	return out, nil
+}
+
+// ids переводит id пользователей в значения BIGINT.
+func ids(users []uint64) []int64 {
+	out := make([]int64, len(users))
+	for i, u := range users {
+		out[i] = int64(u)
+	}
+	return out
+}
+
+func (p *Postgres) SentSince(ctx context.Context, user uint64, since time.Time) (int, error) {
+	var n int
+	err := p.pool.QueryRow(ctx,
+		`SELECT count(*) FROM friend_request_log WHERE requester = $1 AND sent_at >= $2`,
+		int64(user), since,
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count sent requests: %w", err)
+	}
+	return n, nil
+}
+
+func (p *Postgres) PruneSent(ctx context.Context, before time.Time) error {
+	if _, err := p.pool.Exec(ctx, `DELETE FROM friend_request_log WHERE sent_at < $1`, before); err != nil {
+		return fmt.Errorf("prune request log: %w", err)
+	}
+	return nil
+}
+
+// expireSQL пропускает строки, заблокированные Update (он выбирает строку
+// пары FOR UPDATE) или проходом другой реплики, - такие заявки истекут
+// при следующем проходе. Update, который начался позже, ждет конца
+// прохода и читает уже истекшую заявку.
+var expireSQL = fmt.Sprintf(`
+	UPDATE friendships SET status = %[2]d, updated_at = $2
+	WHERE (user_low, user_high) IN (
+		SELECT user_low, user_high FROM friendships
+		WHERE status = %[1]d AND created_at <= $1
+		ORDER BY created_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	)`, Pending, Expired)
+
+func (p *Postgres) ExpirePending(ctx context.Context, before, now time.Time, limit int) (int, error) {
+	tag, err := p.pool.Exec(ctx, expireSQL, before, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("expire requests: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// leaveGroupsSQL убирает бывших друзей $1 и $2 из групп друг друга.
+const leaveGroupsSQL = `
+	DELETE FROM friend_group_members m
+	USING friend_groups g
+	WHERE m.group_id = g.id
+	  AND ((g.owner_id = $1 AND m.member_id = $2) OR (g.owner_id = $2 AND m.member_id = $1))`
+
+// uniqueViolation - код ошибки PostgreSQL при нарушении уникального индекса.
+const uniqueViolation = "23505"
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+// queryer - пул или транзакция.
+type queryer interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// loadGroup читает группу owner с участниками; suffix дописывается
+// к выборке группы, например FOR UPDATE.
+func loadGroup(ctx context.Context, q queryer, owner, id uint64, suffix string) (Group, error) {
+	g := Group{ID: id, Owner: owner}
+	err := q.QueryRow(ctx, `
+		SELECT name, created_at, updated_at
+		FROM friend_groups
+		WHERE owner_id = $1 AND id = $2`+suffix, int64(owner), int64(id),
+	).Scan(&g.Name, &g.CreatedAt, &g.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Group{}, ErrGroupNotFound
+	}
+	if err != nil {
+		return Group{}, fmt.Errorf("select group: %w", err)
+	}
+
+	members, err := groupMembers(ctx, q, []uint64{id})
+	if err != nil {
+		return Group{}, err
+	}
+	g.Members = members[id]
+	return g, nil
+}
+
+// groupMembers возвращает участников каждой из групп по возрастанию id.
+func groupMembers(ctx context.Context, q queryer, groups []uint64) (map[uint64][]uint64, error) {
+	rows, err := q.Query(ctx,
+		`SELECT group_id, member_id FROM friend_group_members WHERE group_id = ANY($1)`, ids(groups))
+	if err != nil {
+		return nil, fmt.Errorf("select group members: %w", err)
+	}
+
+	out := make(map[uint64][]uint64, len(groups))
+	var group, member int64
+	_, err = pgx.ForEachRow(rows, []any{&group, &member}, func() error {
+		out[uint64(group)] = append(out[uint64(group)], uint64(member))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select group members: %w", err)
+	}
+	// в BIGINT id больше 2^63 отрицательны, поэтому порядок - после чтения
+	for _, members := range out {
+		slices.Sort(members)
+	}
+	return out, nil
+}
+
+// addMembers добавляет друзей owner в группу id. Пара owner и друга
+// блокируется, как в Update: дружба не закончится, пока транзакция
+// не завершится, и удаление из групп не разминется с добавлением.
+func addMembers(ctx context.Context, tx pgx.Tx, owner, id uint64, members []uint64, maxMembers int, now time.Time) error {
+	for _, member := range members {
+		low, high := rowKey(owner, member)
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, lockKey(low, high)); err != nil {
+			return fmt.Errorf("lock pair: %w", err)
+		}
+		var friends bool
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM friendships
+				WHERE user_low = $1 AND user_high = $2 AND status = $3
+			)`, low, high, Accepted,
+		).Scan(&friends)
+		if err != nil {
+			return fmt.Errorf("select friendship: %w", err)
+		}
+		if !friends {
+			return ErrNotFriend
+		}
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO friend_group_members (group_id, member_id, added_at)
+		SELECT $1, unnest($2::bigint[]), $3
+		ON CONFLICT DO NOTHING`, int64(id), ids(members), now)
+	if err != nil {
+		return fmt.Errorf("insert group members: %w", err)
+	}
+
+	var n int
+	err = tx.QueryRow(ctx, `SELECT count(*) FROM friend_group_members WHERE group_id = $1`, int64(id)).Scan(&n)
+	if err != nil {
+		return fmt.Errorf("count group members: %w", err)
+	}
+	if n > maxMembers {
+		return ErrMemberLimit
+	}
+	return nil
+}
+
+func (p *Postgres) CreateGroup(ctx context.Context, g Group, maxMembers int) (Group, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return Group{}, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var id int64
+	err = tx.QueryRow(ctx, `
+		INSERT INTO friend_groups (owner_id, name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`, int64(g.Owner), g.Name, g.CreatedAt, g.UpdatedAt,
+	).Scan(&id)
+	if isUniqueViolation(err) {
+		return Group{}, ErrGroupExists
+	}
+	if err != nil {
+		return Group{}, fmt.Errorf("insert group: %w", err)
+	}
+	g.ID = uint64(id)
+
+	if err := addMembers(ctx, tx, g.Owner, g.ID, g.Members, maxMembers, g.CreatedAt); err != nil {
+		return Group{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Group{}, fmt.Errorf("commit: %w", err)
+	}
+	return g, nil
+}
+
+func (p *Postgres) Groups(ctx context.Context, owner uint64) ([]Group, error) {
+	rows, err := p.pool.Query(ctx, `
+		SELECT id, name, created_at, updated_at
+		FROM friend_groups
+		WHERE owner_id = $1
+		ORDER BY created_at, id`, int64(owner))
+	if err != nil {
+		return nil, fmt.Errorf("select groups: %w", err)
+	}
+
+	out := []Group{}
+	g := Group{Owner: owner}
+	var id int64
+	_, err = pgx.ForEachRow(rows, []any{&id, &g.Name, &g.CreatedAt, &g.UpdatedAt}, func() error {
+		g.ID = uint64(id)
+		out = append(out, g)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select groups: %w", err)
+	}
+
+	groups := make([]uint64, len(out))
+	for i, g := range out {
+		groups[i] = g.ID
+	}
+	members, err := groupMembers(ctx, p.pool, groups)
+	if err != nil {
+		return nil, err
+	}
+	for i := range out {
+		out[i].Members = members[out[i].ID]
+	}
+	return out, nil
+}
+
+func (p *Postgres) Group(ctx context.Context, owner, id uint64) (Group, error) {
+	return loadGroup(ctx, p.pool, owner, id, "")
+}
+
+func (p *Postgres) RenameGroup(ctx context.Context, owner, id uint64, name string, now time.Time) (Group, error) {
+	tag, err := p.pool.Exec(ctx,
+		`UPDATE friend_groups SET name = $3, updated_at = $4 WHERE owner_id = $1 AND id = $2`,
+		int64(owner), int64(id), name, now)
+	if isUniqueViolation(err) {
+		return Group{}, ErrGroupExists
+	}
+	if err != nil {
+		return Group{}, fmt.Errorf("rename group: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return Group{}, ErrGroupNotFound
+	}
+	return loadGroup(ctx, p.pool, owner, id, "")
+}
+
+func (p *Postgres) DeleteGroup(ctx context.Context, owner, id uint64) error {
+	tag, err := p.pool.Exec(ctx, `DELETE FROM friend_groups WHERE owner_id = $1 AND id = $2`, int64(owner), int64(id))
+	if err != nil {
+		return fmt.Errorf("delete group: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrGroupNotFound
+	}
+	return nil
+}
+
+// updateGroup блокирует группу owner, меняет ее в fn и возвращает
+// группу после изменения.
+func (p *Postgres) updateGroup(ctx context.Context, owner, id uint64, now time.Time, fn func(tx pgx.Tx) error) (Group, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return Group{}, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := loadGroup(ctx, tx, owner, id, " FOR UPDATE"); err != nil {
+		return Group{}, err
+	}
+	if err := fn(tx); err != nil {
+		return Group{}, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE friend_groups SET updated_at = $2 WHERE id = $1`, int64(id), now); err != nil {
+		return Group{}, fmt.Errorf("update group: %w", err)
+	}
+	g, err := loadGroup(ctx, tx, owner, id, "")
+	if err != nil {
+		return Group{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Group{}, fmt.Errorf("commit: %w", err)
+	}
+	return g, nil
+}
+
+func (p *Postgres) AddGroupMembers(ctx context.Context, owner, id uint64, members []uint64, maxMembers int, now time.Time) (Group, error) {
+	return p.updateGroup(ctx, owner, id, now, func(tx pgx.Tx) error {
+		return addMembers(ctx, tx, owner, id, members, maxMembers, now)
+	})
+}
+
+func (p *Postgres) RemoveGroupMember(ctx context.Context, owner, id, member uint64, now time.Time) (Group, error) {
+	return p.updateGroup(ctx, owner, id, now, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM friend_group_members WHERE group_id = $1 AND member_id = $2`,
+			int64(id), int64(member))
+		if err != nil {
+			return fmt.Errorf("delete group member: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotMember
+		}
+		return nil
+	})
+}
@@ -0,0 +1,40 @@
+package friendship
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestPostgres прогоняет сценарии Store на PostgreSQL из RELATIONS_TEST_DSN.
+// Тест очищает таблицы relations, поэтому база должна быть отдельной.
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("RELATIONS_TEST_DSN")
+	if dsn == "" {
+		t.Skip("RELATIONS_TEST_DSN is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatal(err)
+	}
+
+	newRepo := func(t *testing.T) Repository {
+		_, err := pool.Exec(ctx, `TRUNCATE friendships, blocks, friend_request_log, friend_group_members, friend_groups`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewPostgres(pool)
+	}
+	t.Run("transitions", func(t *testing.T) { testTransitions(t, newRepo) })
+	t.Run("limits", func(t *testing.T) { testLimits(t, newRepo) })
+	t.Run("groups", func(t *testing.T) { testGroups(t, newRepo) })
+	t.Run("suggestions", func(t *testing.T) { testSuggest(t, newRepo) })
+}
@@ -0,0 +1,100 @@
+package friendship
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// Suggestion - пользователь, которого можно добавить в друзья.
+type Suggestion struct {
+	UserID uint64
+	// Mutual - сколько у него общих друзей с пользователем.
+	Mutual int
+}
+
+// Suggest возвращает до limit друзей друзей пользователя, больше всего
+// общих друзей - первыми. Друзья, заявки и блокировки в любую сторону
+// пропускаются. Просматриваются только Rules.SuggestScan последних
+// друзей, поэтому у пользователя с тысячами друзей счет приблизительный.
+func (s *Store) Suggest(ctx context.Context, user uint64, limit int) ([]Suggestion, error) {
+	mine, err := s.repo.Friends(ctx, []uint64{user}, s.rules.SuggestScan)
+	if err != nil {
+		return nil, err
+	}
+	friends := mine[user]
+	if len(friends) == 0 {
+		return nil, nil
+	}
+
+	theirs, err := s.repo.Friends(ctx, friends, s.rules.SuggestScan)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[uint64]bool, len(friends)+1)
+	known[user] = true
+	for _, f := range friends {
+		known[f] = true
+	}
+	mutual := make(map[uint64]int)
+	for _, list := range theirs {
+		for _, candidate := range list {
+			if !known[candidate] {
+				mutual[candidate]++
+			}
+		}
+	}
+
+	ranked := make([]Suggestion, 0, len(mutual))
+	for id, n := range mutual {
+		ranked = append(ranked, Suggestion{UserID: id, Mutual: n})
+	}
+	slices.SortFunc(ranked, func(a, b Suggestion) int {
+		return cmp.Or(cmp.Compare(b.Mutual, a.Mutual), cmp.Compare(a.UserID, b.UserID))
+	})
+
+	// заявки и блокировки проверяются только у лучших кандидатов,
+	// пачками, пока не наберется limit
+	out := make([]Suggestion, 0, min(limit, len(ranked)))
+	for len(ranked) > 0 && len(out) < limit {
+		batch := ranked[:min(len(ranked), 2*limit)]
+		ranked = ranked[len(batch):]
+
+		ids := make([]uint64, 0, len(batch))
+		for _, c := range batch {
+			ids = append(ids, c.UserID)
+		}
+		related, err := s.repo.Related(ctx, user, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range batch {
+			if !related[c.UserID] && len(out) < limit {
+				out = append(out, c)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Mutual возвращает общих друзей a и b по возрастанию id.
+func (s *Store) Mutual(ctx context.Context, a, b uint64) ([]uint64, error) {
+	friends, err := s.repo.Friends(ctx, []uint64{a, b}, s.rules.MutualScan)
+	if err != nil {
+		return nil, err
+	}
+
+	ofA := make(map[uint64]bool, len(friends[a]))
+	for _, f := range friends[a] {
+		ofA[f] = true
+	}
+	var out []uint64
+	for _, f := range friends[b] {
+		if ofA[f] {
+			out = append(out, f)
+		}
+	}
+	slices.Sort(out)
+	return out, nil
+}
@@ -0,0 +1,88 @@
+package friendship
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_messenger/relations/internal/events"
+)
+
+// testSuggest проверяет счет общих друзей, исключения и границы просмотра.
+func testSuggest(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+	rules := Rules{DailyRequests: 50, RequestTTL: time.Hour, SuggestScan: 10, MutualScan: 10}
+
+	t.Run("ranked by mutual friends", func(t *testing.T) {
+		s := NewStore(newRepo(t), events.NewLocal(64), rules)
+		befriend(t, s, 1, 2, 3, 4)
+		befriend(t, s, 2, 3, 5, 6, 9)
+		befriend(t, s, 3, 5, 6, 7)
+		befriend(t, s, 4, 5, 8)
+		// 7 уже получил заявку, 8 заблокирован пользователем, 9 заблокировал его
+		if _, err := s.Send(ctx, 1, 7); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Block(ctx, 1, 8); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Block(ctx, 9, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := s.Suggest(ctx, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []Suggestion{{UserID: 5, Mutual: 3}, {UserID: 6, Mutual: 2}}
+		if !slices.Equal(got, want) {
+			t.Errorf("Suggest = %v, want %v", got, want)
+		}
+		if got, err := s.Suggest(ctx, 1, 1); err != nil || !slices.Equal(got, want[:1]) {
+			t.Errorf("Suggest with limit 1 = %v, %v, want %v", got, err, want[:1])
+		}
+
+		mutual, err := s.Mutual(ctx, 1, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(mutual, []uint64{2, 3, 4}) {
+			t.Errorf("Mutual(1, 5) = %v, want [2 3 4]", mutual)
+		}
+		if mutual, err := s.Mutual(ctx, 2, 3); err != nil || !slices.Equal(mutual, []uint64{1, 5, 6}) {
+			t.Errorf("Mutual(2, 3) = %v, %v, want [1 5 6]", mutual, err)
+		}
+	})
+
+	t.Run("no friends", func(t *testing.T) {
+		s := NewStore(newRepo(t), events.NewLocal(8), rules)
+		if got, err := s.Suggest(ctx, 1, 10); err != nil || len(got) != 0 {
+			t.Errorf("Suggest = %v, %v, want none", got, err)
+		}
+	})
+
+	t.Run("scan is bounded", func(t *testing.T) {
+		scan := rules
+		scan.SuggestScan, scan.MutualScan = 1, 1
+		s := NewStore(newRepo(t), events.NewLocal(64), scan)
+		// последний друг 1 - это 3, а последний друг 3 - это 11
+		befriend(t, s, 1, 2, 3)
+		befriend(t, s, 2, 10)
+		befriend(t, s, 3, 11)
+
+		got, err := s.Suggest(ctx, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []Suggestion{{UserID: 11, Mutual: 1}}; !slices.Equal(got, want) {
+			t.Errorf("Suggest = %v, want %v", got, want)
+		}
+
+		// у 1 и 4 общие 2 и 3, но сравниваются только последние друзья
+		befriend(t, s, 4, 2, 3)
+		if mutual, err := s.Mutual(ctx, 1, 4); err != nil || !slices.Equal(mutual, []uint64{3}) {
+			t.Errorf("Mutual = %v, %v, want [3]", mutual, err)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+// Package policy проверяет связи пользователей через CheckRelation
+// и кеширует ответы, пока WatchRelations не сообщит, что связь изменилась.
+package policy
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	pb "github.com/zura-t/go_messenger/relations/pkg/relations"
+	"github.com/zura-t/go_messenger/relations/pkg/watch"
+)
+
+// Relation - связь пользователя с другим.
+type Relation struct {
+	State pb.RelationState
+	// Outgoing - пользователь отправил заявку или заблокировал другого.
+	Outgoing bool
+}
+
+func (r Relation) Friends() bool {
+	return r.State == pb.RelationState_RELATION_STATE_FRIEND
+}
+
+// Blocked - один из пары заблокировал другого.
+func (r Relation) Blocked() bool {
+	return r.State == pb.RelationState_RELATION_STATE_BLOCKED
+}
+
+type pair struct{ user, target uint64 }
+
+type entry struct {
+	relation Relation
+	expires  time.Time
+}
+
+// Cache хранит не больше maxEntries ответов CheckRelation, каждый не
+// дольше ttl: так устаревают изменения, о которых событие не придет,
+// например истекшие заявки или связи, измененные через другую реплику
+// relations. Пока Run не подписан на события, ответы не кешируются.
+type Cache struct {
+	client     pb.RelationsServiceClient
+	ttl        time.Duration
+	maxEntries int
+
+	mx      sync.Mutex
+	entries map[pair]entry
+	synced  bool
+	// generation растет с каждым событием: ответ, запрошенный до него,
+	// мог устареть и не сохраняется
+	generation uint64
+}
+
+func New(client pb.RelationsServiceClient, ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		client:     client,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[pair]entry),
+	}
+}
+
+// Check возвращает связь user с target из кеша или из relations.
+func (c *Cache) Check(ctx context.Context, user, target uint64) (Relation, error) {
+	key := pair{user, target}
+	c.mx.Lock()
+	if e, ok := c.entries[key]; ok && time.Now().Before(e.expires) {
+		c.mx.Unlock()
+		return e.relation, nil
+	}
+	generation := c.generation
+	c.mx.Unlock()
+
+	resp, err := c.client.CheckRelation(ctx, &pb.CheckRelationRequest{UserId: user, TargetId: target})
+	if err != nil {
+		return Relation{}, err
+	}
+	r := Relation{State: resp.GetState(), Outgoing: resp.GetOutgoing()}
+	c.store(key, r, generation)
+	return r, nil
+}
+
+// Run сбрасывает ответы по событиям relations, пока не отменен ctx.
+// Затем каждое событие передается в next, если он задан: так сервису
+// хватает одной подписки.
+func (c *Cache) Run(ctx context.Context, next func(e *pb.RelationEvent)) {
+	watch.Run(ctx, c.client, &pb.WatchRelationsRequest{}, watch.Handler{
+		Event: func(e *pb.RelationEvent) {
+			c.invalidate(e.GetActorId(), e.GetTargetId())
+			if next != nil {
+				next(e)
+			}
+		},
+		Resync:      func() { c.reset(true) },
+		Interrupted: func() { c.reset(false) },
+	})
+}
+
+func (c *Cache) store(key pair, r Relation, generation uint64) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if !c.synced || c.generation != generation {
+		return
+	}
+	if len(c.entries) >= c.maxEntries {
+		c.sweepLocked()
+		if len(c.entries) >= c.maxEntries {
+			return
+		}
+	}
+	c.entries[key] = entry{relation: r, expires: time.Now().Add(c.ttl)}
+}
+
+func (c *Cache) invalidate(a, b uint64) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.generation++
+	delete(c.entries, pair{a, b})
+	delete(c.entries, pair{b, a})
+}
+
+// reset удаляет все ответы; synced - подписка на события действует.
+func (c *Cache) reset(synced bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.generation++
+	c.synced = synced
+	clear(c.entries)
+}
+
+func (c *Cache) sweepLocked() {
+	now := time.Now()
+	for key, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package policy
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc"
+)
+
+// fakeClient отвечает на CheckRelation текущим state. Пока открыт
+// wait, ответ задерживается: так событие успевает прийти во время вызова.
+type fakeClient struct {
+	pb.RelationsServiceClient
+
+	mx    sync.Mutex
+	state pb.RelationState
+	calls int
+	// started получает сигнал, когда вызов начался
+	started chan struct{}
+	wait    chan struct{}
+}
+
+func (f *fakeClient) CheckRelation(context.Context, *pb.CheckRelationRequest, ...grpc.CallOption) (*pb.CheckRelationResponse, error) {
+	f.mx.Lock()
+	f.calls++
+	state, started, wait := f.state, f.started, f.wait
+	f.mx.Unlock()
+
+	if started != nil {
+		started <- struct{}{}
+	}
+	if wait != nil {
+		<-wait
+	}
+	return &pb.CheckRelationResponse{State: state}, nil
+}
+
+func (f *fakeClient) set(state pb.RelationState) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.state = state
+}
+
+func check(t *testing.T, c *Cache, want pb.RelationState) {
+	t.Helper()
+	r, err := c.Check(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.State != want {
+		t.Errorf("state = %v, want %v", r.State, want)
+	}
+}
+
+func TestCacheSynced(t *testing.T) {
+	client := &fakeClient{state: pb.RelationState_RELATION_STATE_FRIEND}
+	c := New(client, time.Minute, 10)
+
+	// пока подписки на события нет, каждый вызов идет в relations
+	check(t, c, pb.RelationState_RELATION_STATE_FRIEND)
+	check(t, c, pb.RelationState_RELATION_STATE_FRIEND)
+	if client.calls != 2 {
+		t.Errorf("calls before sync = %d, want 2", client.calls)
+	}
+
+	c.reset(true)
+	check(t, c, pb.RelationState_RELATION_STATE_FRIEND)
+	check(t, c, pb.RelationState_RELATION_STATE_FRIEND)
+	if client.calls != 3 {
+		t.Errorf("calls after sync = %d, want 3", client.calls)
+	}
+
+	// событие о паре сбрасывает ответ в обе стороны
+	client.set(pb.RelationState_RELATION_STATE_BLOCKED)
+	c.invalidate(2, 1)
+	check(t, c, pb.RelationState_RELATION_STATE_BLOCKED)
+
+	// после обрыва потока кеш снова не используется
+	c.reset(false)
+	client.set(pb.RelationState_RELATION_STATE_NONE)
+	check(t, c, pb.RelationState_RELATION_STATE_NONE)
+	check(t, c, pb.RelationState_RELATION_STATE_NONE)
+	if client.calls != 6 {
+		t.Errorf("calls after interruption = %d, want 6", client.calls)
+	}
+}
+
+func TestCacheDropsAnswerStaleByEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event func(c *Cache)
+	}{
+		{"event about the pair", func(c *Cache) { c.invalidate(1, 2) }},
+		{"event about other users", func(c *Cache) { c.invalidate(3, 4) }},
+		{"stream interrupted and resynced", func(c *Cache) { c.reset(false); c.reset(true) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{
+				state:   pb.RelationState_RELATION_STATE_FRIEND,
+				started: make(chan struct{}),
+				wait:    make(chan struct{}),
+			}
+			c := New(client, time.Minute, 10)
+			c.reset(true)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				check(t, c, pb.RelationState_RELATION_STATE_FRIEND)
+			}()
+			<-client.started
+			// связь изменилась, пока ответ relations был в пути
+			client.set(pb.RelationState_RELATION_STATE_BLOCKED)
+			tt.event(c)
+			close(client.wait)
+			<-done
+
+			client.mx.Lock()
+			client.started, client.wait = nil, nil
+			client.mx.Unlock()
+			check(t, c, pb.RelationState_RELATION_STATE_BLOCKED)
+			if client.calls != 2 {
+				t.Errorf("calls = %d, want 2: the stale answer was cached", client.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,837 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v6.31.0
+// source: relations/service.proto
+
+package relations
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	RelationsService_SendFriendRequest_FullMethodName      = "/go_messenger.RelationsService/SendFriendRequest"
+	RelationsService_RespondToFriendRequest_FullMethodName = "/go_messenger.RelationsService/RespondToFriendRequest"
+	RelationsService_RemoveFriend_FullMethodName           = "/go_messenger.RelationsService/RemoveFriend"
+	RelationsService_ListFriends_FullMethodName            = "/go_messenger.RelationsService/ListFriends"
+	RelationsService_BlockUser_FullMethodName              = "/go_messenger.RelationsService/BlockUser"
+	RelationsService_UnblockUser_FullMethodName            = "/go_messenger.RelationsService/UnblockUser"
+	RelationsService_ListBlocked_FullMethodName            = "/go_messenger.RelationsService/ListBlocked"
+	RelationsService_SuggestFriends_FullMethodName         = "/go_messenger.RelationsService/SuggestFriends"
+	RelationsService_MutualFriends_FullMethodName          = "/go_messenger.RelationsService/MutualFriends"
+	RelationsService_CreateGroup_FullMethodName            = "/go_messenger.RelationsService/CreateGroup"
+	RelationsService_ListGroups_FullMethodName             = "/go_messenger.RelationsService/ListGroups"
+	RelationsService_GetGroup_FullMethodName               = "/go_messenger.RelationsService/GetGroup"
+	RelationsService_RenameGroup_FullMethodName            = "/go_messenger.RelationsService/RenameGroup"
+	RelationsService_DeleteGroup_FullMethodName            = "/go_messenger.RelationsService/DeleteGroup"
+	RelationsService_AddGroupMembers_FullMethodName        = "/go_messenger.RelationsService/AddGroupMembers"
+	RelationsService_RemoveGroupMember_FullMethodName      = "/go_messenger.RelationsService/RemoveGroupMember"
+	RelationsService_CheckBlock_FullMethodName             = "/go_messenger.RelationsService/CheckBlock"
+	RelationsService_CheckRelation_FullMethodName          = "/go_messenger.RelationsService/CheckRelation"
+	RelationsService_WatchRelations_FullMethodName         = "/go_messenger.RelationsService/WatchRelations"
+)
+
+// RelationsServiceClient is the client API for RelationsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RelationsServiceClient interface {
+	SendFriendRequest(ctx context.Context, in *SendFriendRequestRequest, opts ...grpc.CallOption) (*Friendship, error)
+	RespondToFriendRequest(ctx context.Context, in *RespondToFriendRequestRequest, opts ...grpc.CallOption) (*Friendship, error)
+	RemoveFriend(ctx context.Context, in *RemoveFriendRequest, opts ...grpc.CallOption) (*Friendship, error)
+	ListFriends(ctx context.Context, in *ListFriendsRequest, opts ...grpc.CallOption) (*ListFriendsResponse, error)
+	// BlockUser удаляет дружбу с пользователем; его новые заявки
+	// отбрасываются, а сообщения в чате не доставляются.
+	BlockUser(ctx context.Context, in *BlockUserRequest, opts ...grpc.CallOption) (*BlockedUser, error)
+	UnblockUser(ctx context.Context, in *UnblockUserRequest, opts ...grpc.CallOption) (*UnblockUserResponse, error)
+	ListBlocked(ctx context.Context, in *ListBlockedRequest, opts ...grpc.CallOption) (*ListBlockedResponse, error)
+	// SuggestFriends предлагает друзей друзей, с которыми больше всего общих друзей.
+	SuggestFriends(ctx context.Context, in *SuggestFriendsRequest, opts ...grpc.CallOption) (*SuggestFriendsResponse, error)
+	// MutualFriends - общие друзья вызвавшего пользователя и user_id.
+	MutualFriends(ctx context.Context, in *MutualFriendsRequest, opts ...grpc.CallOption) (*MutualFriendsResponse, error)
+	// CreateGroup создает группу друзей; групп не больше relations.max_groups,
+	// друзей в группе - не больше relations.max_group_members.
+	CreateGroup(ctx context.Context, in *CreateGroupRequest, opts ...grpc.CallOption) (*FriendGroup, error)
+	ListGroups(ctx context.Context, in *ListGroupsRequest, opts ...grpc.CallOption) (*ListGroupsResponse, error)
+	// GetGroup также вызывает chat, чтобы начать групповую переписку.
+	GetGroup(ctx context.Context, in *GetGroupRequest, opts ...grpc.CallOption) (*FriendGroup, error)
+	RenameGroup(ctx context.Context, in *RenameGroupRequest, opts ...grpc.CallOption) (*FriendGroup, error)
+	DeleteGroup(ctx context.Context, in *DeleteGroupRequest, opts ...grpc.CallOption) (*DeleteGroupResponse, error)
+	AddGroupMembers(ctx context.Context, in *AddGroupMembersRequest, opts ...grpc.CallOption) (*FriendGroup, error)
+	RemoveGroupMember(ctx context.Context, in *RemoveGroupMemberRequest, opts ...grpc.CallOption) (*FriendGroup, error)
+	// CheckBlock нужен accounts, чтобы скрывать заблокированных.
+	// Наружу не публикуется.
+	CheckBlock(ctx context.Context, in *CheckBlockRequest, opts ...grpc.CallOption) (*CheckBlockResponse, error)
+	// CheckRelation - связь двух пользователей одним чтением, для проверок
+	// доступа в других сервисах. Они кешируют ответы через relations/pkg/policy
+	// и сбрасывают их по событиям WatchRelations. Наружу не публикуется.
+	CheckRelation(ctx context.Context, in *CheckRelationRequest, opts ...grpc.CallOption) (*CheckRelationResponse, error)
+	// WatchRelations передает изменения связей, сделанные после подписки,
+	// чтобы другие сервисы узнавали о них, не опрашивая relations.
+	// Поток обрывается с UNAVAILABLE, если подписчик не успевает читать или
+	// реплика останавливается; события за время переподключения теряются.
+	// Наружу не публикуется.
+	WatchRelations(ctx context.Context, in *WatchRelationsRequest, opts ...grpc.CallOption) (RelationsService_WatchRelationsClient, error)
+}
+
+type relationsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRelationsServiceClient(cc grpc.ClientConnInterface) RelationsServiceClient {
+	return &relationsServiceClient{cc}
+}
+
+func (c *relationsServiceClient) SendFriendRequest(ctx context.Context, in *SendFriendRequestRequest, opts ...grpc.CallOption) (*Friendship, error) {
+	out := new(Friendship)
+	err := c.cc.Invoke(ctx, RelationsService_SendFriendRequest_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) RespondToFriendRequest(ctx context.Context, in *RespondToFriendRequestRequest, opts ...grpc.CallOption) (*Friendship, error) {
+	out := new(Friendship)
+	err := c.cc.Invoke(ctx, RelationsService_RespondToFriendRequest_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) RemoveFriend(ctx context.Context, in *RemoveFriendRequest, opts ...grpc.CallOption) (*Friendship, error) {
+	out := new(Friendship)
+	err := c.cc.Invoke(ctx, RelationsService_RemoveFriend_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) ListFriends(ctx context.Context, in *ListFriendsRequest, opts ...grpc.CallOption) (*ListFriendsResponse, error) {
+	out := new(ListFriendsResponse)
+	err := c.cc.Invoke(ctx, RelationsService_ListFriends_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) BlockUser(ctx context.Context, in *BlockUserRequest, opts ...grpc.CallOption) (*BlockedUser, error) {
+	out := new(BlockedUser)
+	err := c.cc.Invoke(ctx, RelationsService_BlockUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) UnblockUser(ctx context.Context, in *UnblockUserRequest, opts ...grpc.CallOption) (*UnblockUserResponse, error) {
+	out := new(UnblockUserResponse)
+	err := c.cc.Invoke(ctx, RelationsService_UnblockUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) ListBlocked(ctx context.Context, in *ListBlockedRequest, opts ...grpc.CallOption) (*ListBlockedResponse, error) {
+	out := new(ListBlockedResponse)
+	err := c.cc.Invoke(ctx, RelationsService_ListBlocked_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) SuggestFriends(ctx context.Context, in *SuggestFriendsRequest, opts ...grpc.CallOption) (*SuggestFriendsResponse, error) {
+	out := new(SuggestFriendsResponse)
+	err := c.cc.Invoke(ctx, RelationsService_SuggestFriends_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) MutualFriends(ctx context.Context, in *MutualFriendsRequest, opts ...grpc.CallOption) (*MutualFriendsResponse, error) {
+	out := new(MutualFriendsResponse)
+	err := c.cc.Invoke(ctx, RelationsService_MutualFriends_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) CreateGroup(ctx context.Context, in *CreateGroupRequest, opts ...grpc.CallOption) (*FriendGroup, error) {
+	out := new(FriendGroup)
+	err := c.cc.Invoke(ctx, RelationsService_CreateGroup_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) ListGroups(ctx context.Context, in *ListGroupsRequest, opts ...grpc.CallOption) (*ListGroupsResponse, error) {
+	out := new(ListGroupsResponse)
+	err := c.cc.Invoke(ctx, RelationsService_ListGroups_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) GetGroup(ctx context.Context, in *GetGroupRequest, opts ...grpc.CallOption) (*FriendGroup, error) {
+	out := new(FriendGroup)
+	err := c.cc.Invoke(ctx, RelationsService_GetGroup_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) RenameGroup(ctx context.Context, in *RenameGroupRequest, opts ...grpc.CallOption) (*FriendGroup, error) {
+	out := new(FriendGroup)
+	err := c.cc.Invoke(ctx, RelationsService_RenameGroup_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) DeleteGroup(ctx context.Context, in *DeleteGroupRequest, opts ...grpc.CallOption) (*DeleteGroupResponse, error) {
+	out := new(DeleteGroupResponse)
+	err := c.cc.Invoke(ctx, RelationsService_DeleteGroup_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) AddGroupMembers(ctx context.Context, in *AddGroupMembersRequest, opts ...grpc.CallOption) (*FriendGroup, error) {
+	out := new(FriendGroup)
+	err := c.cc.Invoke(ctx, RelationsService_AddGroupMembers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) RemoveGroupMember(ctx context.Context, in *RemoveGroupMemberRequest, opts ...grpc.CallOption) (*FriendGroup, error) {
+	out := new(FriendGroup)
+	err := c.cc.Invoke(ctx, RelationsService_RemoveGroupMember_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) CheckBlock(ctx context.Context, in *CheckBlockRequest, opts ...grpc.CallOption) (*CheckBlockResponse, error) {
+	out := new(CheckBlockResponse)
+	err := c.cc.Invoke(ctx, RelationsService_CheckBlock_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) CheckRelation(ctx context.Context, in *CheckRelationRequest, opts ...grpc.CallOption) (*CheckRelationResponse, error) {
+	out := new(CheckRelationResponse)
+	err := c.cc.Invoke(ctx, RelationsService_CheckRelation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationsServiceClient) WatchRelations(ctx context.Context, in *WatchRelationsRequest, opts ...grpc.CallOption) (RelationsService_WatchRelationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RelationsService_ServiceDesc.Streams[0], RelationsService_WatchRelations_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &relationsServiceWatchRelationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type RelationsService_WatchRelationsClient interface {
+	Recv() (*RelationEvent, error)
+	grpc.ClientStream
+}
+
+type relationsServiceWatchRelationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *relationsServiceWatchRelationsClient) Recv() (*RelationEvent, error) {
+	m := new(RelationEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// RelationsServiceServer is the server API for RelationsService service.
+// All implementations must embed UnimplementedRelationsServiceServer
+// for forward compatibility
+type RelationsServiceServer interface {
+	SendFriendRequest(context.Context, *SendFriendRequestRequest) (*Friendship, error)
+	RespondToFriendRequest(context.Context, *RespondToFriendRequestRequest) (*Friendship, error)
+	RemoveFriend(context.Context, *RemoveFriendRequest) (*Friendship, error)
+	ListFriends(context.Context, *ListFriendsRequest) (*ListFriendsResponse, error)
+	// BlockUser удаляет дружбу с пользователем; его новые заявки
+	// отбрасываются, а сообщения в чате не доставляются.
+	BlockUser(context.Context, *BlockUserRequest) (*BlockedUser, error)
+	UnblockUser(context.Context, *UnblockUserRequest) (*UnblockUserResponse, error)
+	ListBlocked(context.Context, *ListBlockedRequest) (*ListBlockedResponse, error)
+	// SuggestFriends предлагает друзей друзей, с которыми больше всего общих друзей.
+	SuggestFriends(context.Context, *SuggestFriendsRequest) (*SuggestFriendsResponse, error)
+	// MutualFriends - общие друзья вызвавшего пользователя и user_id.
+	MutualFriends(context.Context, *MutualFriendsRequest) (*MutualFriendsResponse, error)
+	// CreateGroup создает группу друзей; групп не больше relations.max_groups,
+	// друзей в группе - не больше relations.max_group_members.
+	CreateGroup(context.Context, *CreateGroupRequest) (*FriendGroup, error)
+	ListGroups(context.Context, *ListGroupsRequest) (*ListGroupsResponse, error)
+	// GetGroup также вызывает chat, чтобы начать групповую переписку.
+	GetGroup(context.Context, *GetGroupRequest) (*FriendGroup, error)
+	RenameGroup(context.Context, *RenameGroupRequest) (*FriendGroup, error)
+	DeleteGroup(context.Context, *DeleteGroupRequest) (*DeleteGroupResponse, error)
+	AddGroupMembers(context.Context, *AddGroupMembersRequest) (*FriendGroup, error)
+	RemoveGroupMember(context.Context, *RemoveGroupMemberRequest) (*FriendGroup, error)
+	// CheckBlock нужен accounts, чтобы скрывать заблокированных.
+	// Наружу не публикуется.
+	CheckBlock(context.Context, *CheckBlockRequest) (*CheckBlockResponse, error)
+	// CheckRelation - связь двух пользователей одним чтением, для проверок
+	// доступа в других сервисах. Они кешируют ответы через relations/pkg/policy
+	// и сбрасывают их по событиям WatchRelations. Наружу не публикуется.
+	CheckRelation(context.Context, *CheckRelationRequest) (*CheckRelationResponse, error)
+	// WatchRelations передает изменения связей, сделанные после подписки,
+	// чтобы другие сервисы узнавали о них, не опрашивая relations.
+	// Поток обрывается с UNAVAILABLE, если подписчик не успевает читать или
+	// реплика останавливается; события за время переподключения теряются.
+	// Наружу не публикуется.
+	WatchRelations(*WatchRelationsRequest, RelationsService_WatchRelationsServer) error
+	mustEmbedUnimplementedRelationsServiceServer()
+}
+
+// UnimplementedRelationsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedRelationsServiceServer struct {
+}
+
+func (UnimplementedRelationsServiceServer) SendFriendRequest(context.Context, *SendFriendRequestRequest) (*Friendship, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendFriendRequest not implemented")
+}
+func (UnimplementedRelationsServiceServer) RespondToFriendRequest(context.Context, *RespondToFriendRequestRequest) (*Friendship, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RespondToFriendRequest not implemented")
+}
+func (UnimplementedRelationsServiceServer) RemoveFriend(context.Context, *RemoveFriendRequest) (*Friendship, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveFriend not implemented")
+}
+func (UnimplementedRelationsServiceServer) ListFriends(context.Context, *ListFriendsRequest) (*ListFriendsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFriends not implemented")
+}
+func (UnimplementedRelationsServiceServer) BlockUser(context.Context, *BlockUserRequest) (*BlockedUser, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlockUser not implemented")
+}
+func (UnimplementedRelationsServiceServer) UnblockUser(context.Context, *UnblockUserRequest) (*UnblockUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnblockUser not implemented")
+}
+func (UnimplementedRelationsServiceServer) ListBlocked(context.Context, *ListBlockedRequest) (*ListBlockedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBlocked not implemented")
+}
+func (UnimplementedRelationsServiceServer) SuggestFriends(context.Context, *SuggestFriendsRequest) (*SuggestFriendsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SuggestFriends not implemented")
+}
+func (UnimplementedRelationsServiceServer) MutualFriends(context.Context, *MutualFriendsRequest) (*MutualFriendsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MutualFriends not implemented")
+}
+func (UnimplementedRelationsServiceServer) CreateGroup(context.Context, *CreateGroupRequest) (*FriendGroup, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateGroup not implemented")
+}
+func (UnimplementedRelationsServiceServer) ListGroups(context.Context, *ListGroupsRequest) (*ListGroupsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGroups not implemented")
+}
+func (UnimplementedRelationsServiceServer) GetGroup(context.Context, *GetGroupRequest) (*FriendGroup, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGroup not implemented")
+}
+func (UnimplementedRelationsServiceServer) RenameGroup(context.Context, *RenameGroupRequest) (*FriendGroup, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameGroup not implemented")
+}
+func (UnimplementedRelationsServiceServer) DeleteGroup(context.Context, *DeleteGroupRequest) (*DeleteGroupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteGroup not implemented")
+}
+func (UnimplementedRelationsServiceServer) AddGroupMembers(context.Context, *AddGroupMembersRequest) (*FriendGroup, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddGroupMembers not implemented")
+}
+func (UnimplementedRelationsServiceServer) RemoveGroupMember(context.Context, *RemoveGroupMemberRequest) (*FriendGroup, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveGroupMember not implemented")
+}
+func (UnimplementedRelationsServiceServer) CheckBlock(context.Context, *CheckBlockRequest) (*CheckBlockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckBlock not implemented")
+}
+func (UnimplementedRelationsServiceServer) CheckRelation(context.Context, *CheckRelationRequest) (*CheckRelationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckRelation not implemented")
+}
+func (UnimplementedRelationsServiceServer) WatchRelations(*WatchRelationsRequest, RelationsService_WatchRelationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchRelations not implemented")
+}
+func (UnimplementedRelationsServiceServer) mustEmbedUnimplementedRelationsServiceServer() {}
+
+// UnsafeRelationsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RelationsServiceServer will
+// result in compilation errors.
+type UnsafeRelationsServiceServer interface {
+	mustEmbedUnimplementedRelationsServiceServer()
+}
+
+func RegisterRelationsServiceServer(s grpc.ServiceRegistrar, srv RelationsServiceServer) {
+	s.RegisterService(&RelationsService_ServiceDesc, srv)
+}
+
+func _RelationsService_SendFriendRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendFriendRequestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).SendFriendRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_SendFriendRequest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).SendFriendRequest(ctx, req.(*SendFriendRequestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_RespondToFriendRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RespondToFriendRequestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).RespondToFriendRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_RespondToFriendRequest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).RespondToFriendRequest(ctx, req.(*RespondToFriendRequestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_RemoveFriend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveFriendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).RemoveFriend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_RemoveFriend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).RemoveFriend(ctx, req.(*RemoveFriendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_ListFriends_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFriendsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).ListFriends(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_ListFriends_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).ListFriends(ctx, req.(*ListFriendsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_BlockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).BlockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_BlockUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).BlockUser(ctx, req.(*BlockUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_UnblockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnblockUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).UnblockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_UnblockUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).UnblockUser(ctx, req.(*UnblockUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_ListBlocked_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBlockedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).ListBlocked(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_ListBlocked_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).ListBlocked(ctx, req.(*ListBlockedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_SuggestFriends_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuggestFriendsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).SuggestFriends(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_SuggestFriends_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).SuggestFriends(ctx, req.(*SuggestFriendsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_MutualFriends_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MutualFriendsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).MutualFriends(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_MutualFriends_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).MutualFriends(ctx, req.(*MutualFriendsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_CreateGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).CreateGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_CreateGroup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).CreateGroup(ctx, req.(*CreateGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_ListGroups_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGroupsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).ListGroups(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_ListGroups_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).ListGroups(ctx, req.(*ListGroupsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_GetGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).GetGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_GetGroup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).GetGroup(ctx, req.(*GetGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_RenameGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).RenameGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_RenameGroup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).RenameGroup(ctx, req.(*RenameGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_DeleteGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).DeleteGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_DeleteGroup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).DeleteGroup(ctx, req.(*DeleteGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_AddGroupMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddGroupMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).AddGroupMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_AddGroupMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).AddGroupMembers(ctx, req.(*AddGroupMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_RemoveGroupMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveGroupMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).RemoveGroupMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_RemoveGroupMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).RemoveGroupMember(ctx, req.(*RemoveGroupMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_CheckBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).CheckBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_CheckBlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).CheckBlock(ctx, req.(*CheckBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_CheckRelation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRelationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationsServiceServer).CheckRelation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationsService_CheckRelation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationsServiceServer).CheckRelation(ctx, req.(*CheckRelationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationsService_WatchRelations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRelationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RelationsServiceServer).WatchRelations(m, &relationsServiceWatchRelationsServer{stream})
+}
+
+type RelationsService_WatchRelationsServer interface {
+	Send(*RelationEvent) error
+	grpc.ServerStream
+}
+
+type relationsServiceWatchRelationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *relationsServiceWatchRelationsServer) Send(m *RelationEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// RelationsService_ServiceDesc is the grpc.ServiceDesc for RelationsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RelationsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "go_messenger.RelationsService",
+	HandlerType: (*RelationsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SendFriendRequest",
+			Handler:    _RelationsService_SendFriendRequest_Handler,
+		},
+		{
+			MethodName: "RespondToFriendRequest",
+			Handler:    _RelationsService_RespondToFriendRequest_Handler,
+		},
+		{
+			MethodName: "RemoveFriend",
+			Handler:    _RelationsService_RemoveFriend_Handler,
+		},
+		{
+			MethodName: "ListFriends",
+			Handler:    _RelationsService_ListFriends_Handler,
+		},
+		{
+			MethodName: "BlockUser",
+			Handler:    _RelationsService_BlockUser_Handler,
+		},
+		{
+			MethodName: "UnblockUser",
+			Handler:    _RelationsService_UnblockUser_Handler,
+		},
+		{
+			MethodName: "ListBlocked",
+			Handler:    _RelationsService_ListBlocked_Handler,
+		},
+		{
+			MethodName: "SuggestFriends",
+			Handler:    _RelationsService_SuggestFriends_Handler,
+		},
+		{
+			MethodName: "MutualFriends",
+			Handler:    _RelationsService_MutualFriends_Handler,
+		},
+		{
+			MethodName: "CreateGroup",
+			Handler:    _RelationsService_CreateGroup_Handler,
+		},
+		{
+			MethodName: "ListGroups",
+			Handler:    _RelationsService_ListGroups_Handler,
+		},
+		{
+			MethodName: "GetGroup",
+			Handler:    _RelationsService_GetGroup_Handler,
+		},
+		{
+			MethodName: "RenameGroup",
+			Handler:    _RelationsService_RenameGroup_Handler,
+		},
+		{
+			MethodName: "DeleteGroup",
+			Handler:    _RelationsService_DeleteGroup_Handler,
+		},
+		{
+			MethodName: "AddGroupMembers",
+			Handler:    _RelationsService_AddGroupMembers_Handler,
+		},
+		{
+			MethodName: "RemoveGroupMember",
+			Handler:    _RelationsService_RemoveGroupMember_Handler,
+		},
+		{
+			MethodName: "CheckBlock",
+			Handler:    _RelationsService_CheckBlock_Handler,
+		},
+		{
+			MethodName: "CheckRelation",
+			Handler:    _RelationsService_CheckRelation_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchRelations",
+			Handler:       _RelationsService_WatchRelations_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "relations/service.proto",
+}
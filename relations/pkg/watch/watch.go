@@ -0,0 +1,90 @@
+// Package watch держит подписку другого сервиса на WatchRelations.
+package watch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"time"
+
+	pb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 10 * time.Second
+)
+
+// Handler получает события подписки.
+type Handler struct {
+	// Event вызывается для каждого события по порядку.
+	Event func(e *pb.RelationEvent)
+	// Resync вызывается после каждой подписки, включая первую: события,
+	// опубликованные без подписки, потеряны, и все, что из них выведено
+	// (например, кеш), нужно сбросить. Может быть nil.
+	Resync func()
+	// Interrupted вызывается, когда поток оборвался: до следующего Resync
+	// изменения связей не приходят. Может быть nil.
+	Interrupted func()
+}
+
+// Run подписывается на события relations и переподключается с растущей
+// паузой, пока не отменен ctx.
+func Run(ctx context.Context, client pb.RelationsServiceClient, req *pb.WatchRelationsRequest, h Handler) {
+	backoff := minBackoff
+	for {
+		received, err := watch(ctx, client, req, h)
+		if h.Interrupted != nil {
+			h.Interrupted()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if received {
+			backoff = minBackoff
+		}
+		slog.WarnContext(ctx, "relation events stream interrupted", "error", err, "retry_in", backoff)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// watch читает один поток, пока он не оборвется. received - подписка
+// состоялась, и пауза перед следующей начинается заново.
+func watch(ctx context.Context, client pb.RelationsServiceClient, req *pb.WatchRelationsRequest, h Handler) (received bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.WatchRelations(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	if _, err := stream.Header(); err != nil {
+		return false, err
+	}
+	if h.Resync != nil {
+		h.Resync()
+	}
+
+	for {
+		e, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return true, status.Error(codes.Unavailable, "stream closed by relations")
+		}
+		if err != nil {
+			return true, err
+		}
+		h.Event(e)
+	}
+}
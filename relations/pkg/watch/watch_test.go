@@ -0,0 +1,109 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	pb "github.com/zura-t/go_messenger/relations/pkg/relations"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// attempt - ответ на одну подписку: ошибка или поток с events,
+// который затем обрывается.
+type attempt struct {
+	err    error
+	events []*pb.RelationEvent
+}
+
+type fakeClient struct {
+	pb.RelationsServiceClient
+	attempts []attempt
+	// calls - время каждой подписки
+	calls []time.Time
+	// last вызывается вместо подписки, когда attempts кончились
+	last func()
+}
+
+func (f *fakeClient) WatchRelations(ctx context.Context, _ *pb.WatchRelationsRequest, _ ...grpc.CallOption) (pb.RelationsService_WatchRelationsClient, error) {
+	f.calls = append(f.calls, time.Now())
+	if len(f.calls) > len(f.attempts) {
+		f.last()
+		return nil, ctx.Err()
+	}
+	a := f.attempts[len(f.calls)-1]
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &fakeStream{events: a.events}, nil
+}
+
+type fakeStream struct {
+	grpc.ClientStream
+	events []*pb.RelationEvent
+}
+
+func (s *fakeStream) Header() (metadata.MD, error) { return nil, nil }
+
+func (s *fakeStream) Recv() (*pb.RelationEvent, error) {
+	if len(s.events) == 0 {
+		return nil, status.Error(codes.Unavailable, "connection reset")
+	}
+	e := s.events[0]
+	s.events = s.events[1:]
+	return e, nil
+}
+
+func TestRunReconnects(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	client := &fakeClient{
+		attempts: []attempt{
+			{err: unavailable},
+			{err: unavailable},
+			{events: []*pb.RelationEvent{{Id: 1}, {Id: 2}}},
+		},
+		last: cancel,
+	}
+	var got []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(ctx, client, &pb.WatchRelationsRequest{}, Handler{
+			Event:       func(e *pb.RelationEvent) { got = append(got, fmt.Sprintf("event %d", e.GetId())) },
+			Resync:      func() { got = append(got, "resync") },
+			Interrupted: func() { got = append(got, "interrupted") },
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after ctx was canceled")
+	}
+
+	want := []string{"interrupted", "interrupted", "resync", "event 1", "event 2", "interrupted", "interrupted"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("handler calls = %q, want %q", got, want)
+	}
+
+	// пауза растет после каждой неудачной подписки и сбрасывается после
+	// подписки, которая состоялась
+	if len(client.calls) != 4 {
+		t.Fatalf("subscribed %d times, want 4", len(client.calls))
+	}
+	for i, least := range []time.Duration{minBackoff, 2 * minBackoff, minBackoff} {
+		if gap := client.calls[i+1].Sub(client.calls[i]); gap < least {
+			t.Errorf("pause before subscription %d = %v, want at least %v", i+2, gap, least)
+		}
+	}
+	if gap := client.calls[3].Sub(client.calls[2]); gap >= 4*minBackoff {
+		t.Errorf("pause after a working subscription = %v, want it reset to about %v", gap, minBackoff)
+	}
+}